@@ -0,0 +1,249 @@
+package zlog
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// Archiver 在过期日志被清理删除前接收文件，返回 nil 后文件才会被删除。
+type Archiver interface {
+	Archive(ctx context.Context, path string) error
+}
+
+// ArchiverFunc 将普通函数适配为 Archiver。
+type ArchiverFunc func(ctx context.Context, path string) error
+
+// Archive 实现 Archiver。
+func (f ArchiverFunc) Archive(ctx context.Context, path string) error {
+	return f(ctx, path)
+}
+
+// ObjectStore 是 S3 兼容对象存储的最小上传接口，由调用方基于自己的 SDK 实现。
+type ObjectStore interface {
+	PutObject(ctx context.Context, key string, body io.Reader, size int64) error
+}
+
+// defaultArchiveTimeout 是归档单个文件的默认超时。
+const defaultArchiveTimeout = 5 * time.Minute
+
+// archive 在超时内归档 path。归档器未遵守 ctx 而超时时不再等待并返回错误，文件保留到下一轮清理重试；
+// 上一次超时的调用尚未结束时不再启动新的归档，同一轮清理中卡住的归档器最多占用一个 goroutine。
+func (c *cleanupRun) archive(path string) error {
+	if c.pending != nil {
+		select {
+		case <-c.pending:
+			c.pending = nil
+		default:
+			return fmt.Errorf("zlog: archive %s: %w", path, errArchiveBusy)
+		}
+	}
+
+	timeout := c.timeout
+	if timeout <= 0 {
+		timeout = defaultArchiveTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- c.archiver.Archive(ctx, path) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		c.pending = done
+		return fmt.Errorf("zlog: archive %s: %w", path, ctx.Err())
+	}
+}
+
+// errArchiveBusy 表示上一次超时的归档调用仍未返回。
+var errArchiveBusy = errors.New("previous archive still running")
+
+// ctxReader 在 ctx 结束后停止读取，使复制大文件的归档器能按超时退出。
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+// Read 实现 io.Reader。
+func (r ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// dirArchiver 将日志文件复制到归档目录。
+type dirArchiver struct {
+	dir string
+}
+
+// NewDirArchiver 返回将日志文件原样复制到 dir（保留文件名）的归档器。
+func NewDirArchiver(dir string) Archiver {
+	return &dirArchiver{dir: dir}
+}
+
+// Archive 复制文件并落盘，目标已存在时覆盖。
+func (a *dirArchiver) Archive(ctx context.Context, src string) error {
+	if err := ensureDir(a.dir); err != nil {
+		return err
+	}
+	dst := filepath.Join(a.dir, filepath.Base(src))
+	tmp := dst + ".tmp"
+	if err := copyFile(ctx, src, tmp); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+// tarArchiver 按文件日期将日志追加到 <dir>/<YYYY-MM-DD>.tar.gz。
+type tarArchiver struct {
+	dir string
+	sem chan struct{} // 串行化写入，等待时遵守 ctx，避免卡住的调用让之后的归档全部超时
+}
+
+// NewTarArchiver 返回按天打包的归档器：同一天的日志文件追加到同一个 <dir>/<YYYY-MM-DD>.tar.gz。
+func NewTarArchiver(dir string) Archiver {
+	return &tarArchiver{dir: dir, sem: make(chan struct{}, 1)}
+}
+
+// Archive 将文件追加到对应日期的 tar.gz，只写入这一个文件，不重写已有内容。
+// 每个文件是一个独立的 gzip 成员且不写 tar 结束块，tar 与 Go 的 gzip/tar 读取时视为连续的归档；
+// 同名文件再次归档时追加在后，解包时后者覆盖前者。
+func (a *tarArchiver) Archive(ctx context.Context, src string) error {
+	select {
+	case a.sem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-a.sem }()
+
+	if err := ensureDir(a.dir); err != nil {
+		return err
+	}
+	tarball := filepath.Join(a.dir, archiveDay(src)+".tar.gz")
+	out, err := os.OpenFile(tarball, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	// 记录原长度，写入失败时截断回去，不留下不完整的成员
+	offset, err := out.Seek(0, io.SeekEnd)
+	if err != nil {
+		out.Close()
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+
+	err = addTarFile(ctx, tw, src)
+	if err == nil {
+		err = tw.Flush()
+	}
+	if cerr := gz.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = out.Sync()
+	}
+	if err != nil {
+		_ = out.Truncate(offset)
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// objectStoreArchiver 将日志上传到对象存储。
+type objectStoreArchiver struct {
+	store  ObjectStore
+	prefix string
+}
+
+// NewObjectStoreArchiver 返回上传到对象存储的归档器，对象 key 为 <keyPrefix><YYYY-MM-DD>/<文件名>。
+func NewObjectStoreArchiver(store ObjectStore, keyPrefix string) Archiver {
+	return &objectStoreArchiver{store: store, prefix: keyPrefix}
+}
+
+// Archive 上传文件，只有上传成功才返回 nil。
+func (a *objectStoreArchiver) Archive(ctx context.Context, src string) error {
+	fh, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	info, err := fh.Stat()
+	if err != nil {
+		return err
+	}
+	key := a.prefix + path.Join(archiveDay(src), filepath.Base(src))
+	if err := a.store.PutObject(ctx, key, fh, info.Size()); err != nil {
+		return fmt.Errorf("zlog: upload %s: %w", key, err)
+	}
+	return nil
+}
+
+// archiveDay 返回归档使用的日期目录，无法解析文件名时使用 undated。
+func archiveDay(src string) string {
+	if lf, ok := ParseLogFileName(src); ok {
+		return lf.Time.Format("2006-01-02")
+	}
+	return "undated"
+}
+
+// copyFile 复制文件内容并同步到磁盘，ctx 结束时中止。
+func copyFile(ctx context.Context, src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, ctxReader{ctx: ctx, r: in}); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// addTarFile 将单个文件写入 tw，ctx 结束时中止。
+func addTarFile(ctx context.Context, tw *tar.Writer, src string) error {
+	fh, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	info, err := fh.Stat()
+	if err != nil {
+		return err
+	}
+	hdr, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	hdr.Name = filepath.Base(src)
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, ctxReader{ctx: ctx, r: fh})
+	return err
+}
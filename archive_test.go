@@ -0,0 +1,243 @@
+package zlog
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeObjectStore 是内存中的对象存储，fail 为 true 时所有上传失败。
+type fakeObjectStore struct {
+	mu      sync.Mutex
+	fail    bool
+	objects map[string][]byte
+}
+
+func (s *fakeObjectStore) PutObject(_ context.Context, key string, body io.Reader, size int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail {
+		return errors.New("store unavailable")
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) != size {
+		return errors.New("size mismatch")
+	}
+	if s.objects == nil {
+		s.objects = make(map[string][]byte)
+	}
+	s.objects[key] = data
+	return nil
+}
+
+// TestCleanupArchivesBeforeDelete 测试归档成功后才删除过期文件
+func TestCleanupArchivesBeforeDelete(t *testing.T) {
+	tmpDir := t.TempDir()
+	expired := filepath.Join(tmpDir, "svc_info2020-01-01.log")
+	if err := os.WriteFile(expired, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := &fakeObjectStore{fail: true}
+	archiver := NewObjectStoreArchiver(store, "logs/")
+
+	t.Log("上传失败时保留文件")
+	report, err := runCleanup(cleanupOptions{dir: tmpDir, maxAge: 24, archiver: archiver})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Failures) != 1 || len(report.Deleted) != 0 || len(report.Archived) != 0 {
+		t.Fatalf("unexpected report on failed upload: %+v", report)
+	}
+	if _, err := os.Stat(expired); err != nil {
+		t.Fatalf("file must be kept when archiving fails: %v", err)
+	}
+
+	t.Log("上传成功后删除文件")
+	store.fail = false
+	report, err = runCleanup(cleanupOptions{dir: tmpDir, maxAge: 24, archiver: archiver})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Archived) != 1 || len(report.Deleted) != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if _, err := os.Stat(expired); !os.IsNotExist(err) {
+		t.Fatalf("expected expired file removed, err=%v", err)
+	}
+	if got := string(store.objects["logs/2020-01-01/svc_info2020-01-01.log"]); got != "old" {
+		t.Fatalf("unexpected object content %q (objects=%v)", got, store.objects)
+	}
+}
+
+// TestDirArchiver 测试目录归档器
+func TestDirArchiver(t *testing.T) {
+	tmpDir := t.TempDir()
+	archiveDir := filepath.Join(t.TempDir(), "cold")
+	expired := filepath.Join(tmpDir, "svc_info2020-01-01.log")
+	if err := os.WriteFile(expired, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := runCleanup(cleanupOptions{dir: tmpDir, maxAge: 24, archiver: NewDirArchiver(archiveDir)}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(archiveDir, "svc_info2020-01-01.log"))
+	if err != nil || string(data) != "old" {
+		t.Fatalf("archived copy missing: %q, %v", data, err)
+	}
+}
+
+// TestTarArchiver 测试按天打包归档器
+func TestTarArchiver(t *testing.T) {
+	tmpDir := t.TempDir()
+	archiveDir := t.TempDir()
+	for _, name := range []string{"a_info2020-01-01.log", "b_info2020-01-01.log", "a_info2020-01-02.log"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := runCleanup(cleanupOptions{dir: tmpDir, maxAge: 24, archiver: NewTarArchiver(archiveDir)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Archived) != 3 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	names := readTarNames(t, filepath.Join(archiveDir, "2020-01-01.tar.gz"))
+	if len(names) != 2 || names[0] != "a_info2020-01-01.log" || names[1] != "b_info2020-01-01.log" {
+		t.Fatalf("unexpected tarball entries: %v", names)
+	}
+	names = readTarNames(t, filepath.Join(archiveDir, "2020-01-02.tar.gz"))
+	if len(names) != 1 {
+		t.Fatalf("unexpected tarball entries: %v", names)
+	}
+
+	t.Log("之后的文件追加到已有 tar.gz，不重写已有内容")
+	tarball := filepath.Join(archiveDir, "2020-01-01.tar.gz")
+	before, err := os.ReadFile(tarball)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "c_info2020-01-01.log"), []byte("c"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runCleanup(cleanupOptions{dir: tmpDir, maxAge: 24, archiver: NewTarArchiver(archiveDir)}); err != nil {
+		t.Fatal(err)
+	}
+	after, err := os.ReadFile(tarball)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(after, before) || len(after) == len(before) {
+		t.Fatal("expected the new file to be appended to the existing tarball")
+	}
+	if names := readTarNames(t, tarball); len(names) != 3 || names[2] != "c_info2020-01-01.log" {
+		t.Fatalf("unexpected tarball entries: %v", names)
+	}
+}
+
+// TestTarArchiverHonorsContext 测试上一次归档卡住时，之后的调用按 ctx 超时返回而不是一直等待
+func TestTarArchiverHonorsContext(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "svc_info2020-01-01.log")
+	if err := os.WriteFile(src, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	archiver := NewTarArchiver(t.TempDir()).(*tarArchiver)
+
+	archiver.sem <- struct{}{} // 模拟仍在写入的调用
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := archiver.Archive(ctx, src); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the timeout, got %v", err)
+	}
+
+	<-archiver.sem
+	if err := archiver.Archive(context.Background(), src); err != nil {
+		t.Fatalf("archive after release: %v", err)
+	}
+}
+
+func readTarNames(t *testing.T, path string) []string {
+	t.Helper()
+	fh, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fh.Close()
+	gz, err := gzip.NewReader(fh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TestArchiveTimeout 测试归档卡住时按超时放弃并保留文件，清理不会被阻塞
+func TestArchiveTimeout(t *testing.T) {
+	tmpDir := t.TempDir()
+	expired := filepath.Join(tmpDir, "svc_info2020-01-01.log")
+	if err := os.WriteFile(expired, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	second := filepath.Join(tmpDir, "svc_info2020-01-02.log")
+	if err := os.WriteFile(second, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 不遵守 ctx 的归档器，模拟卡住的上传
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	var calls int32
+	hung := ArchiverFunc(func(context.Context, string) error {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return nil
+	})
+
+	report, err := runCleanup(cleanupOptions{dir: tmpDir, maxAge: 24, archiver: hung, archiveTimeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Failures) != 2 || !errors.Is(report.Failures[0].Err, context.DeadlineExceeded) || len(report.Deleted) != 0 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	for _, path := range []string{expired, second} {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("file must be kept when archiving times out: %v", err)
+		}
+	}
+
+	t.Log("上一次调用仍卡住时不再启动新的归档")
+	if n := atomic.LoadInt32(&calls); n != 1 || !errors.Is(report.Failures[1].Err, errArchiveBusy) {
+		t.Fatalf("expected one archive call and a busy failure, got %d calls: %v", n, report.Failures[1].Err)
+	}
+}
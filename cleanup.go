@@ -5,15 +5,13 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 )
 
-// 包级正则表达式，避免重复编译
-var logDateRegex = regexp.MustCompile(`(\d{4}-\d{2}-\d{2})\.log(?:\.\d+)?$`)
-
 // clearLog 清理超出保留时长的旧日志文件，同时处理软链接。
 // 改进版本：扫描整个日志目录，清理所有过期文件（包括不再使用的 logger）
 func clearLog() {
@@ -22,26 +20,109 @@ func clearLog() {
 
 // clearLogWithConfig 使用指定配置清理日志，如果 cfg 为 nil，使用全局配置
 func clearLogWithConfig(cfg *Config) {
-	var maxAge int
 	if cfg == nil {
 		// 使用全局配置
 		globalCfg := getConfig()
-		maxAge = globalCfg.WithMaxAge
-	} else {
-		maxAge = cfg.WithMaxAge
+		cfg = &globalCfg
 	}
 
-	logPath := logDir()
+	_, _ = runCleanup(cleanupOptions{dir: logDir(), maxAge: cfg.WithMaxAge, maxFiles: cfg.MaxFiles, archiver: cfg.Archiver, archiveTimeout: cfg.ArchiveTimeout, prefixes: configuredPrefixes(*cfg, nil)})
+}
+
+// CleanupCandidates 返回按 maxAgeHours 与 maxFiles（与 WithMaxFiles 一致，<=0 表示不限制）清理 dir 时
+// 将被删除的文件与失效软链接，不做任何修改。归档器只决定删除前是否先归档，不影响结果。
+// 目录中没有清单时无法得知进程配置的前缀，会列出所有符合命名规则的日志。
+func CleanupCandidates(dir string, maxAgeHours, maxFiles int) ([]string, error) {
+	report, err := runCleanup(cleanupOptions{dir: dir, maxAge: maxAgeHours, maxFiles: maxFiles, dryRun: true})
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(report.Deleted)+len(report.Symlinks))
+	for _, f := range report.Deleted {
+		paths = append(paths, f.Path)
+	}
+	return append(paths, report.Symlinks...), nil
+}
+
+// CleanupReport 描述一次日志清理的结果，DryRun 时 Deleted/Symlinks 表示将被删除的文件。
+type CleanupReport struct {
+	Dir            string
+	DryRun         bool
+	Emergency      bool // 由磁盘已满触发的紧急清理
+	StartedAt      time.Time
+	Duration       time.Duration
+	Deleted        []CleanupFile    // 过期的日志文件
+	Archived       []string         // 删除前已成功归档的文件
+	Symlinks       []string         // 失效的软链接
+	Failures       []CleanupFailure // 归档或删除失败的文件，文件仍保留（紧急清理中归档失败的文件仍会删除）
+	BytesReclaimed int64            // 已（或将）回收的字节数
+}
+
+// CleanupFile 描述一个被清理的日志文件。
+type CleanupFile struct {
+	Path string
+	Size int64
+}
+
+// CleanupFailure 描述一次删除失败。
+type CleanupFailure struct {
+	Path string
+	Err  error
+}
+
+// cleanupOptions 描述一次清理的参数。
+type cleanupOptions struct {
+	dir      string
+	maxAge   int
+	maxFiles int // 每个文件前缀保留的最新时间戳数，<=0 表示不限制
+	dryRun   bool
+	archiver Archiver // 非空时过期文件先归档，成功后才删除
+	// archiveTimeout 是归档单个文件的超时，<=0 表示 defaultArchiveTimeout
+	archiveTimeout time.Duration
+	// prefixes 是目录中没有清单时认领的文件前缀（本进程配置的 logger），nil 表示按文件名规则认领全部日志，仅供 dry-run 工具使用
+	prefixes map[string]bool
+}
+
+// cleanupRun 记录一次清理过程中的删除决策，dryRun 时只记录不删除。
+type cleanupRun struct {
+	dir      string
+	dryRun   bool
+	archiver Archiver
+	timeout  time.Duration // 归档单个文件的超时
+	force    bool          // 归档失败时仍删除文件，用于磁盘已满时的紧急清理
+	pending  <-chan error  // 超时后仍未返回的归档调用
+	removed  map[string]bool
+	owned    map[string]bool // 清单中登记的文件前缀，没有清单时为 cleanupOptions.prefixes
+	report   *CleanupReport
+}
+
+// runCleanup 扫描日志目录，删除过期文件与失效软链接；dryRun 时只计算将被删除的文件。
+func runCleanup(opts cleanupOptions) (*CleanupReport, error) {
+	dir, maxAge := opts.dir, opts.maxAge
+	report := &CleanupReport{Dir: dir, DryRun: opts.dryRun, StartedAt: time.Now()}
+	defer func() { report.Duration = time.Since(report.StartedAt) }()
+	run := &cleanupRun{
+		dir:      dir,
+		dryRun:   opts.dryRun,
+		archiver: opts.archiver,
+		timeout:  opts.archiveTimeout,
+		removed:  make(map[string]bool),
+		report:   report,
+	}
 
 	// 扫描日志目录
-	entries, err := os.ReadDir(logPath)
+	entries, err := os.ReadDir(dir)
 	if err != nil {
-		return
+		return report, err
+	}
+	run.owned = opts.prefixes
+	if owned, ok := readManifest(dir); ok {
+		run.owned = owned
 	}
 
 	now := time.Now().In(location)
-	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
 	expireWindow := time.Duration(maxAge) * time.Hour
+	dated := make(map[string][]LogFile)
 
 	for _, entry := range entries {
 		if entry.IsDir() {
@@ -49,40 +130,198 @@ func clearLogWithConfig(cfg *Config) {
 		}
 
 		fileName := entry.Name()
-		fullPath := filepath.Join(logPath, fileName)
+		fullPath := filepath.Join(dir, fileName)
 
-		// 只处理日志文件
-		if !isLogFile(fileName) {
+		// 只处理 zlog 创建的日志文件
+		if !run.owns(fileName) {
 			continue
 		}
 
-		// 提取日期
-		ts := extractLogDate(fileName)
-		if ts == nil {
+		// 提取时间戳
+		stamp, ok := ParseLogFileName(fileName)
+		if !ok {
 			// 没有日期的文件（可能是软链接）
 			if isSymlink(fullPath) {
 				// 检查软链接是否有效
-				if !isValidSymlink(fullPath) {
-					_ = os.Remove(fullPath)
+				if !run.validSymlink(fullPath) {
+					run.remove(fullPath, true)
 				}
 			}
 			continue
 		}
 
-		// 删除过期文件
-		if midnight.Sub(*ts) > expireWindow {
-			_ = os.Remove(fullPath)
+		// 删除过期文件：按文件名精度截断当前时间（天 -> 零点，小时 -> 整点）后比较
+		if truncateLocal(now, stamp.Precision).Sub(stamp.Time) > expireWindow {
+			run.remove(fullPath, false)
+		}
+		dated[stamp.Prefix] = append(dated[stamp.Prefix], stamp)
+	}
+
+	// 按文件数保留：每个前缀只保留最新的 maxFiles 个时间戳
+	if opts.maxFiles > 0 {
+		for _, files := range dated {
+			run.enforceMaxFiles(files, opts.maxFiles)
 		}
 	}
 
 	// 第二遍：清理所有已失效的软链接（避免因处理顺序导致遗留）
-	cleanInvalidSymlinks(logPath)
+	run.cleanInvalidSymlinks()
+	return report, nil
 }
 
-// isLogFile 判断是否是日志文件
+// defaultEmergencyFreeBytes 是磁盘已满时紧急清理默认释放的字节数。
+const defaultEmergencyFreeBytes = 64 << 20
+
+// runEmergencyCleanup 在磁盘已满时按时间从旧到新删除 zlog 创建的日志，直到至少释放 freeBytes。
+// 每个前缀最新的文件（正在写入）始终保留，不受保留时长限制；归档失败（如本地归档器同样写满磁盘）时仍删除文件，失败记入 Failures。
+func runEmergencyCleanup(opts cleanupOptions, freeBytes int64) (*CleanupReport, error) {
+	report := &CleanupReport{Dir: opts.dir, Emergency: true, StartedAt: time.Now()}
+	defer func() { report.Duration = time.Since(report.StartedAt) }()
+	run := &cleanupRun{
+		dir:      opts.dir,
+		archiver: opts.archiver,
+		timeout:  opts.archiveTimeout,
+		force:    true,
+		removed:  make(map[string]bool),
+		report:   report,
+	}
+
+	entries, err := os.ReadDir(opts.dir)
+	if err != nil {
+		return report, err
+	}
+	run.owned = opts.prefixes
+	if owned, ok := readManifest(opts.dir); ok {
+		run.owned = owned
+	}
+
+	newest := make(map[string]time.Time)
+	var candidates []LogFile
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !run.owns(entry.Name()) {
+			continue
+		}
+		stamp, ok := ParseLogFileName(entry.Name())
+		if !ok {
+			continue
+		}
+		candidates = append(candidates, stamp)
+		if stamp.Time.After(newest[stamp.Prefix]) {
+			newest[stamp.Prefix] = stamp.Time
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if !candidates[i].Time.Equal(candidates[j].Time) {
+			return candidates[i].Time.Before(candidates[j].Time)
+		}
+		return candidates[i].Name < candidates[j].Name
+	})
+	for _, f := range candidates {
+		if report.BytesReclaimed >= freeBytes {
+			break
+		}
+		if f.Time.Equal(newest[f.Prefix]) {
+			continue
+		}
+		run.remove(filepath.Join(opts.dir, f.Name), false)
+	}
+
+	run.cleanInvalidSymlinks()
+	return report, nil
+}
+
+// remove 记录并（非 dryRun 时）删除文件，失败时记入 Failures 并视为未删除。
+func (c *cleanupRun) remove(path string, symlink bool) {
+	if c.removed[path] {
+		return
+	}
+
+	var size int64
+	if info, err := os.Lstat(path); err == nil && !symlink {
+		size = info.Size()
+	}
+	if !c.dryRun && !symlink && c.archiver != nil {
+		// 归档失败时保留文件，等待下一轮清理重试；紧急清理必须释放空间，仍然删除
+		if err := c.archive(path); err != nil {
+			c.report.Failures = append(c.report.Failures, CleanupFailure{Path: path, Err: err})
+			if !c.force {
+				return
+			}
+		} else {
+			c.report.Archived = append(c.report.Archived, path)
+		}
+	}
+	if !c.dryRun {
+		if err := os.Remove(path); err != nil {
+			c.report.Failures = append(c.report.Failures, CleanupFailure{Path: path, Err: err})
+			return
+		}
+	}
+
+	c.removed[path] = true
+	if symlink {
+		c.report.Symlinks = append(c.report.Symlinks, path)
+		return
+	}
+	c.report.Deleted = append(c.report.Deleted, CleanupFile{Path: path, Size: size})
+	c.report.BytesReclaimed += size
+}
+
+// enforceMaxFiles 删除同一前缀下除最新 maxFiles 个时间戳以外的文件。
+// 同一时间戳的 .N 分片与 .gz 压缩文件视为同一个文件，一起保留或删除。
+func (c *cleanupRun) enforceMaxFiles(files []LogFile, maxFiles int) {
+	sort.Slice(files, func(i, j int) bool { return files[i].Time.After(files[j].Time) })
+
+	kept := 0
+	var last time.Time
+	for _, f := range files {
+		if kept == 0 || !f.Time.Equal(last) {
+			kept++
+			last = f.Time
+		}
+		if kept > maxFiles {
+			c.remove(filepath.Join(c.dir, f.Name), false)
+		}
+	}
+}
+
+// validSymlink 判断软链接是否有效，指向本轮已删除（或将删除）文件的软链接视为失效。
+func (c *cleanupRun) validSymlink(path string) bool {
+	if !isValidSymlink(path) {
+		return false
+	}
+	target, err := os.Readlink(path)
+	if err != nil {
+		return false
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(path), target)
+	}
+	return !c.removed[filepath.Clean(target)]
+}
+
+// logFileSuffix 匹配 zlog 日志文件的后缀：.log、rotatelogs 分片 .log.N 及压缩后的 .gz。
+var logFileSuffix = regexp.MustCompile(`\.log(\.\d+)?(\.gz)?$`)
+
+// isLogFile 判断是否是日志文件（不再把 blog.log.bak 这类仅包含 ".log" 的文件当作日志）
 func isLogFile(fileName string) bool {
-	// 以 .log 结尾，或包含日期模式
-	return strings.Contains(fileName, ".log")
+	return logFileSuffix.MatchString(fileName)
+}
+
+// owns 判断文件是否由 zlog 创建：前缀必须登记在清单中（没有清单时为本进程配置的前缀）；
+// 两者都没有时按文件名规则判断。
+func (c *cleanupRun) owns(fileName string) bool {
+	if !isLogFile(fileName) {
+		return false
+	}
+	if c.owned == nil {
+		return true
+	}
+	if stamp, ok := ParseLogFileName(fileName); ok {
+		return c.owned[stamp.Prefix]
+	}
+	return c.owned[loggerPrefixFromLink(fileName)]
 }
 
 // isSymlink 判断是否是软链接
@@ -101,8 +340,8 @@ func isValidSymlink(path string) bool {
 }
 
 // cleanInvalidSymlinks 删除日志目录中指向不存在目标的软链接。
-func cleanInvalidSymlinks(dir string) {
-	entries, err := os.ReadDir(dir)
+func (c *cleanupRun) cleanInvalidSymlinks() {
+	entries, err := os.ReadDir(c.dir)
 	if err != nil {
 		return
 	}
@@ -110,85 +349,111 @@ func cleanInvalidSymlinks(dir string) {
 		if entry.Type()&os.ModeSymlink == 0 {
 			continue
 		}
-		fullPath := filepath.Join(dir, entry.Name())
+		if !c.owns(entry.Name()) {
+			continue
+		}
+		fullPath := filepath.Join(c.dir, entry.Name())
 		// 目标正常存在则保留
-		if isValidSymlink(fullPath) {
+		if c.validSymlink(fullPath) {
 			continue
 		}
 
 		// 如果仍有同前缀的实际日志文件（可能是硬链接或新文件）则保留
 		prefix := loggerPrefixFromLink(entry.Name())
-		if hasRegularLogWithPrefix(dir, prefix) {
+		if c.hasRegularLogWithPrefix(prefix) {
 			continue
 		}
 
 		// 目标缺失且无同前缀文件，删除软链接
-		_ = os.Remove(fullPath)
+		c.remove(fullPath, true)
 	}
 }
 
-// loggerPrefixFromLink 提取软链接名中的 logger 前缀（例如 log_info.log -> log）。
+// loggerPrefixFromLink 提取软链接对应的文件前缀（例如 order_api_info.log -> order_api_info）。
+// 软链接名与带日期的文件共享同一前缀，不能按下划线截断，否则 order_api_info 与 order_info 会相互混淆。
 func loggerPrefixFromLink(linkName string) string {
-	base := filepath.Base(linkName)
-	if idx := strings.Index(base, "_"); idx > 0 {
-		return base[:idx]
-	}
-	if idx := strings.Index(base, "."); idx > 0 {
-		return base[:idx]
-	}
-	return base
+	return strings.TrimSuffix(filepath.Base(linkName), ".log")
 }
 
-// hasRegularLogWithPrefix 检查目录下是否存在给定前缀的常规日志文件。
-func hasRegularLogWithPrefix(dir, prefix string) bool {
+// hasRegularLogWithPrefix 检查目录下是否存在给定前缀、且未被本轮删除的常规日志文件。
+func (c *cleanupRun) hasRegularLogWithPrefix(prefix string) bool {
 	if prefix == "" {
 		return false
 	}
-	entries, err := os.ReadDir(dir)
+	entries, err := os.ReadDir(c.dir)
 	if err != nil {
 		return false
 	}
 	for _, e := range entries {
-		if e.Type().IsRegular() && strings.HasPrefix(e.Name(), prefix) && strings.Contains(e.Name(), ".log") {
+		if c.removed[filepath.Join(c.dir, e.Name())] {
+			continue
+		}
+		if !e.Type().IsRegular() {
+			continue
+		}
+		if stamp, ok := ParseLogFileName(e.Name()); ok && stamp.Prefix == prefix {
 			return true
 		}
 	}
 	return false
 }
 
-// extractLogDate 从文件名提取日期
+// extractLogDate 从文件名提取时间戳（日期，或小于一天切割时的小时/分钟）
 func extractLogDate(fileName string) *time.Time {
-	match := logDateRegex.FindStringSubmatch(fileName)
-	if match == nil || len(match) < 2 {
-		return nil
-	}
-
-	const layout = "2006-01-02"
-	ts, err := time.ParseInLocation(layout, match[1], location)
-	if err != nil {
+	stamp, ok := ParseLogFileName(fileName)
+	if !ok {
 		return nil
 	}
-
-	return &ts
+	return &stamp.Time
 }
 
 // CleanupTask 后台清理任务管理器
 type CleanupTask struct {
-	interval time.Duration
-	ticker   *time.Ticker
-	stopChan chan struct{}
-	running  atomic.Bool
-	mu       sync.Mutex
+	interval   time.Duration
+	schedule   schedule
+	jitter     time.Duration
+	runOnStart bool
+	key        string // 创建任务时的调度配置摘要
+	cleanup    func()
+	timer      *time.Timer
+	stopChan   chan struct{}
+	running    atomic.Bool
+	mu         sync.Mutex
 }
 
-// newCleanupTask 创建新的清理任务
-func newCleanupTask(interval time.Duration) *CleanupTask {
+// newCleanupTask 创建按固定间隔执行的清理任务，cleanup 为空时使用全局配置清理；
+// interval 不大于 0 时使用默认的 24 小时，避免计时器立即触发形成忙循环
+func newCleanupTask(interval time.Duration, cleanup func()) *CleanupTask {
+	if cleanup == nil {
+		cleanup = clearLog
+	}
+	if interval <= 0 {
+		interval = defaultCleanupInterval
+	}
 	return &CleanupTask{
 		interval: interval,
+		schedule: intervalSchedule{interval: interval},
+		cleanup:  cleanup,
 		stopChan: make(chan struct{}),
 	}
 }
 
+// newScheduledCleanupTask 按配置中的调度方式（间隔、每日定时或 cron）创建清理任务。
+func newScheduledCleanupTask(cfg Config, cleanup func()) (*CleanupTask, error) {
+	task := newCleanupTask(cfg.CleanupInterval, cleanup)
+	task.jitter = cfg.CleanupJitter
+	task.runOnStart = cfg.CleanupOnStart
+	task.key = cfg.cleanupScheduleKey()
+
+	sched, err := cfg.cleanupSchedule()
+	if err != nil {
+		// 表达式无效时退回固定间隔，保证清理不会停止
+		return task, err
+	}
+	task.schedule = sched
+	return task, nil
+}
+
 // Start 启动后台清理任务
 func (t *CleanupTask) Start() {
 	if !t.running.CompareAndSwap(false, true) {
@@ -198,26 +463,51 @@ func (t *CleanupTask) Start() {
 	go t.run()
 }
 
-// run 执行清理任务循环
+// run 执行清理任务循环：按计划计算下一次执行时间并叠加随机抖动
 func (t *CleanupTask) run() {
 	defer t.running.Store(false)
 
-	t.mu.Lock()
-	t.ticker = time.NewTicker(t.interval)
-	t.mu.Unlock()
-
-	defer t.ticker.Stop()
+	if t.runOnStart {
+		select {
+		case <-t.stopChan:
+			return
+		default:
+			t.cleanup()
+		}
+	}
 
 	for {
+		next := t.nextRun(time.Now())
+		if next.IsZero() {
+			// 计划永远不会触发，只等待停止
+			<-t.stopChan
+			return
+		}
+
+		t.mu.Lock()
+		t.timer = time.NewTimer(time.Until(next))
+		timer := t.timer
+		t.mu.Unlock()
+
 		select {
-		case <-t.ticker.C:
-			clearLog()
+		case <-timer.C:
+			t.cleanup()
 		case <-t.stopChan:
+			timer.Stop()
 			return
 		}
 	}
 }
 
+// nextRun 返回 now 之后的下一次执行时间（含抖动），计划无后续时间时返回零值
+func (t *CleanupTask) nextRun(now time.Time) time.Time {
+	next := t.schedule.Next(now)
+	if next.IsZero() {
+		return next
+	}
+	return withJitter(next, t.jitter)
+}
+
 // Stop 停止清理任务
 func (t *CleanupTask) Stop() {
 	if !t.running.Load() {
@@ -235,24 +525,26 @@ func (t *CleanupTask) Stop() {
 	}
 }
 
+// stopped 返回任务是否已被 Stop
+func (t *CleanupTask) stopped() bool {
+	select {
+	case <-t.stopChan:
+		return true
+	default:
+		return false
+	}
+}
+
 // IsRunning 返回清理任务是否正在运行
 func (t *CleanupTask) IsRunning() bool {
 	return t.running.Load()
 }
 
-// getLogDate 解析日志文件名中的日期部分，并返回文件前缀。
+// getLogDate 解析日志文件名中的时间戳部分，并返回文件前缀。
 func getLogDate(logFileName string) (prefix string, logDate *time.Time, err error) {
-	re := regexp.MustCompile(`^(.*?)(\d{4}-\d{2}-\d{2})\.log(?:\.\d+)?$`)
-	match := re.FindStringSubmatch(logFileName)
-	if match == nil || len(match) != 3 {
+	stamp, ok := ParseLogFileName(logFileName)
+	if !ok {
 		return "", nil, errors.New("no date found in string")
 	}
-
-	const layout = "2006-01-02"
-	ts, err := time.ParseInLocation(layout, match[2], location)
-	if err != nil {
-		return match[1], &ts, err
-	}
-
-	return match[1], &ts, nil
+	return stamp.Prefix, &stamp.Time, nil
 }
@@ -3,6 +3,8 @@ package zlog
 import (
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -14,7 +16,7 @@ func TestCleanupTask(t *testing.T) {
 	SetLog(ENV_DEBUG, WithMaxAge(24*8)) // 保留 8 天的
 
 	// 创建测试 Manager，配置短间隔便于测试
-	mgr := NewManager(
+	mgr, _ := newTestManager(t,
 		WithAutoCleanup(true),
 		WithCleanupInterval(2*time.Second), // 2秒清理一次
 		WithMaxAge(1),                      // 保留1小时
@@ -77,7 +79,7 @@ func TestCleanupRemovesBrokenSymlink(t *testing.T) {
 	}
 
 	// 使用 1 小时保留期触发删除
-	clearLogWithConfig(&Config{WithMaxAge: 1})
+	clearLogWithConfig(&Config{WithMaxAge: 1, DefaultLoggerName: "foo"})
 
 	// 软链接应被移除
 	if _, err := os.Lstat(linkName); err == nil {
@@ -95,7 +97,7 @@ func TestCleanupRemovesBrokenSymlink(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	clearLogWithConfig(&Config{WithMaxAge: 1})
+	clearLogWithConfig(&Config{WithMaxAge: 1, DefaultLoggerName: "foo"})
 
 	if _, err := os.Lstat(linkName); err != nil {
 		t.Fatalf("软链接被误删: %v", err)
@@ -136,7 +138,7 @@ func TestDisableAutoCleanup(t *testing.T) {
 	t.Log("=== 测试禁用自动清理 ===")
 
 	// 创建禁用自动清理的 Manager
-	mgr := NewManager(WithAutoCleanup(false))
+	mgr, _ := newTestManager(t, WithAutoCleanup(false))
 
 	// 验证清理任务未启动
 	if mgr.IsCleanupRunning() {
@@ -152,7 +154,7 @@ func TestCleanupIntervalChange(t *testing.T) {
 	t.Log("=== 测试动态修改清理间隔 ===")
 
 	// 创建 Manager
-	mgr := NewManager(
+	mgr, _ := newTestManager(t,
 		WithAutoCleanup(true),
 		WithCleanupInterval(5*time.Second),
 	)
@@ -186,6 +188,34 @@ func TestCleanupIntervalChange(t *testing.T) {
 	t.Log("=== 测试完成 ===")
 }
 
+// TestCleanupTaskConcurrentRestart 测试并发重启、停止与 Close 清理任务时没有数据竞争（需配合 -race）
+func TestCleanupTaskConcurrentRestart(t *testing.T) {
+	mgr, _ := newTestManager(t, WithAutoCleanup(true), WithCleanupInterval(time.Hour))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				// 交替修改清理间隔，迫使任务重启
+				mgr.SetLog(ENV_INFO, WithCleanupInterval(time.Duration(i*20+j+1)*time.Minute))
+				_ = mgr.IsCleanupRunning()
+			}
+		}(i)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		mgr.StopCleanupTask()
+		_ = mgr.Close()
+	}()
+	wg.Wait()
+
+	mgr.StopCleanupTask()
+	waitFor(t, func() bool { return !mgr.IsCleanupRunning() })
+}
+
 // TestGlobalCleanupAPIs 测试全局清理 API
 func TestGlobalCleanupAPIs(t *testing.T) {
 	t.Log("=== 测试全局清理 API ===")
@@ -218,6 +248,8 @@ func TestExtractLogDate(t *testing.T) {
 		{"test_info.log", false},
 		{"random_file.txt", false},
 		{"2025-01-15.log", true},
+		{"test_info2025-01-15-13.log", true},
+		{"test_info2025-01-15-13-45.log.1", true},
 	}
 
 	for _, tc := range testCases {
@@ -251,6 +283,10 @@ func TestIsLogFile(t *testing.T) {
 		{"test.log.1", true},
 		{"test.txt", false},
 		{"readme.md", false},
+		{"test2025-01-15.log.2.gz", true},
+		{"blog.log.bak", false},
+		{"catalog.json", false},
+		{".zlog-manifest", false},
 	}
 
 	for _, tc := range testCases {
@@ -264,3 +300,262 @@ func TestIsLogFile(t *testing.T) {
 
 	t.Log("=== 测试完成 ===")
 }
+
+// TestCleanupCandidatesDryRun 验证 dry-run 只列出文件而不删除，并包含将失效的软链接
+func TestCleanupCandidatesDryRun(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	expired := filepath.Join(tmpDir, "foo_info2020-01-01.log")
+	if err := os.WriteFile(expired, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(tmpDir, "foo_info.log")
+	if err := os.Symlink(filepath.Base(expired), link); err != nil {
+		t.Fatal(err)
+	}
+	fresh := filepath.Join(tmpDir, "bar_info"+time.Now().Format("2006-01-02")+".log")
+	if err := os.WriteFile(fresh, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	paths, err := CleanupCandidates(tmpDir, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{expired: true, link: true}
+	if len(paths) != len(want) {
+		t.Fatalf("expected %d candidates, got %v", len(want), paths)
+	}
+	for _, path := range paths {
+		if !want[path] {
+			t.Fatalf("unexpected candidate %s", path)
+		}
+		if _, err := os.Lstat(path); err != nil {
+			t.Fatalf("dry-run removed %s", path)
+		}
+	}
+}
+
+// TestCleanupLogsReport 验证报告统计与 dry-run 行为
+func TestCleanupLogsReport(t *testing.T) {
+	var reports []CleanupReport
+	mgr, tmpDir := newTestManager(t,
+		WithMaxAge(24),
+		WithCleanupCallback(func(r CleanupReport) { reports = append(reports, r) }),
+		WithCleanupLogger("zlog_cleanup"),
+		WithDefaultName("svc"),
+	)
+
+	expired := filepath.Join(tmpDir, "svc_info2020-01-01.log")
+	if err := os.WriteFile(expired, []byte("0123456789"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(tmpDir, "svc_info.log")
+	if err := os.Symlink(filepath.Base(expired), link); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := mgr.CleanupLogsReport(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.DryRun || len(report.Deleted) != 1 || len(report.Symlinks) != 1 || report.BytesReclaimed != 10 {
+		t.Fatalf("unexpected dry-run report: %+v", report)
+	}
+	if _, err := os.Stat(expired); err != nil {
+		t.Fatalf("dry-run must not delete: %v", err)
+	}
+	if len(reports) != 0 {
+		t.Fatal("callback should only fire for CleanupLogs and background runs")
+	}
+
+	mgr.CleanupLogs()
+	if _, err := os.Lstat(expired); !os.IsNotExist(err) {
+		t.Fatalf("expected expired file removed, err=%v", err)
+	}
+	if _, err := os.Lstat(link); !os.IsNotExist(err) {
+		t.Fatalf("expected broken symlink removed, err=%v", err)
+	}
+	if len(reports) != 1 || reports[0].DryRun || reports[0].Deleted[0].Path != expired {
+		t.Fatalf("unexpected callback reports: %+v", reports)
+	}
+
+	// 清理结果写入指定通道
+	content := readTodayLog(t, tmpDir, "zlog_cleanup_info")
+	if !strings.Contains(content, "zlog cleanup finished") || !strings.Contains(content, `"deleted":1`) {
+		t.Fatalf("cleanup channel missing summary: %q", content)
+	}
+}
+
+// TestCleanupMaxFiles 测试按文件数保留，前缀包含下划线时互不影响
+func TestCleanupMaxFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	today := time.Now().In(location)
+	day := func(offset int) string { return today.AddDate(0, 0, -offset).Format("2006-01-02") }
+
+	names := []string{
+		"order_api_info" + day(0) + ".log",
+		"order_api_info" + day(1) + ".log",
+		"order_api_info" + day(1) + ".log.1", // 同一天的分片
+		"order_api_info" + day(2) + ".log",
+		"order_api_info" + day(3) + ".log.gz",
+		"order_info" + day(2) + ".log",
+		"order_info" + day(3) + ".log",
+	}
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := runCleanup(cleanupOptions{dir: tmpDir, maxAge: 24 * 30, maxFiles: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var deleted []string
+	for _, f := range report.Deleted {
+		deleted = append(deleted, filepath.Base(f.Path))
+	}
+	want := map[string]bool{
+		"order_api_info" + day(2) + ".log":    true,
+		"order_api_info" + day(3) + ".log.gz": true,
+	}
+	if len(deleted) != len(want) {
+		t.Fatalf("unexpected deletions: %v", deleted)
+	}
+	for _, name := range deleted {
+		if !want[name] {
+			t.Fatalf("unexpected deletion %s (all: %v)", name, deleted)
+		}
+	}
+}
+
+// TestLoggerPrefixFromLink 测试软链接前缀提取不按下划线截断
+func TestLoggerPrefixFromLink(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "order_api_info2025-01-15.log"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(tmpDir, "order_info.log")
+	if err := os.Symlink("order_info2025-01-01.log", link); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := loggerPrefixFromLink("order_api_info.log"); got != "order_api_info" {
+		t.Fatalf("loggerPrefixFromLink = %q", got)
+	}
+
+	run := &cleanupRun{dir: tmpDir, removed: make(map[string]bool), report: &CleanupReport{}}
+	if run.hasRegularLogWithPrefix("order_info") {
+		t.Fatal("order_api_info files must not count as order_info")
+	}
+	run.cleanInvalidSymlinks()
+	if _, err := os.Lstat(link); !os.IsNotExist(err) {
+		t.Fatalf("expected broken order_info.log removed, err=%v", err)
+	}
+}
+
+// TestCleanupOnlyTouchesOwnedFiles 测试存在清单时只清理 zlog 登记过的前缀
+func TestCleanupOnlyTouchesOwnedFiles(t *testing.T) {
+	mgr, tmpDir := newTestManager(t, WithMaxAge(24))
+	mgr.Logger("order_api").Info("登记前缀")
+	mgr.Sync("order_api")
+
+	owned, ok := readManifest(tmpDir)
+	if !ok || !owned["order_api_info"] {
+		t.Fatalf("manifest missing order_api_info: %v", owned)
+	}
+
+	ownedFile := filepath.Join(tmpDir, "order_api_info2020-01-01.log")
+	foreign := []string{
+		"order_info2020-01-01.log", // 形似 zlog 文件但前缀未登记
+		"backup2020-01-01.log",
+		"blog.log.bak",
+		"catalog.json",
+	}
+	for _, name := range append(foreign, filepath.Base(ownedFile)) {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	foreignLink := filepath.Join(tmpDir, "order_info.log")
+	if err := os.Symlink("missing2020-01-01.log", foreignLink); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := mgr.CleanupLogsReport(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Deleted) != 1 || report.Deleted[0].Path != ownedFile || len(report.Symlinks) != 0 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	for _, name := range foreign {
+		if _, err := os.Stat(filepath.Join(tmpDir, name)); err != nil {
+			t.Fatalf("foreign file %s must be kept: %v", name, err)
+		}
+	}
+	if _, err := os.Lstat(foreignLink); err != nil {
+		t.Fatalf("foreign symlink must be kept: %v", err)
+	}
+}
+
+// TestRegisterLogFileAppendsOnce 测试重复打开 writer 时清单只追加新前缀，清单被删除后重建并补回已登记的前缀
+func TestRegisterLogFileAppendsOnce(t *testing.T) {
+	tmpDir := t.TempDir()
+	for i := 0; i < 20; i++ {
+		for _, name := range []string{"api_info.log", "order_info.log"} {
+			if err := registerLogFile(filepath.Join(tmpDir, name)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	content, err := os.ReadFile(filepath.Join(tmpDir, manifestFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(content), "api_info\n") != 1 || strings.Count(string(content), "order_info\n") != 1 {
+		t.Fatalf("expected each prefix once: %q", content)
+	}
+
+	t.Log("清单被删除后，下一次登记重建完整清单")
+	if err := os.Remove(filepath.Join(tmpDir, manifestFileName)); err != nil {
+		t.Fatal(err)
+	}
+	if err := registerLogFile(filepath.Join(tmpDir, "pay_info.log")); err != nil {
+		t.Fatal(err)
+	}
+	owned, ok := readManifest(tmpDir)
+	if !ok || !owned["api_info"] || !owned["order_info"] || !owned["pay_info"] {
+		t.Fatalf("expected the rebuilt manifest to keep registered prefixes, got %v", owned)
+	}
+}
+
+// TestCleanupWithoutManifest 测试目录中没有清单时只认领本实例配置的前缀，不删除其他应用的日志
+func TestCleanupWithoutManifest(t *testing.T) {
+	mgr, tmpDir := newTestManager(t, WithMaxAge(24), WithDefaultName("svc"))
+	own := []string{"svc_info2020-01-01.log", "svc_error2020-01-01.log"}
+	foreign := []string{"other_info2020-01-01.log", "app2020-01-01.log", "svc_v2_info2020-01-01.log"}
+	for _, name := range append(own, foreign...) {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok := readManifest(tmpDir); ok {
+		t.Fatal("test requires a directory without a manifest")
+	}
+
+	report, err := mgr.CleanupLogsReport(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Deleted) != len(own) {
+		t.Fatalf("expected only own files deleted, got %+v", report.Deleted)
+	}
+	for _, name := range foreign {
+		if _, err := os.Stat(filepath.Join(tmpDir, name)); err != nil {
+			t.Fatalf("foreign file %s must be kept: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Xuzan9396/zlog"
+	"github.com/Xuzan9396/zlog/query"
+	"go.uber.org/zap/zapcore"
+)
+
+// splitList 解析逗号分隔的参数。
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// runGrep 按条件过滤日志并输出原始 JSON 行或美化格式。
+func runGrep(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("grep", flag.ContinueOnError)
+	dir := fs.String("dir", defaultDir, "日志目录")
+	logger := fs.String("logger", "", "logger 名称，为空表示全部")
+	kinds := fs.String("kind", "", "文件种类，逗号分隔，例如 info,error")
+	level := fs.String("level", "", "最低等级，例如 warn")
+	since := fs.String("since", "", "起始时间：2h、2006-01-02、2006-01-02 15:04:05")
+	until := fs.String("until", "", "截止时间，格式同 -since")
+	caller := fs.String("caller", "", "调用方子串，例如 order.go:42")
+	msg := fs.String("msg", "", "消息子串")
+	layout := fs.String("time-layout", "", "time 字段的 Go 时间格式，默认按 ZLOG_FORMAT")
+	pretty := fs.Bool("pretty", false, "以终端格式输出")
+	limit := fs.Int("limit", 0, "最多输出条数，0 表示不限制")
+	fields := fieldFlags{}
+	fs.Var(fields, "field", "字段等值匹配 key=value，可重复")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	q := query.Query{
+		Dir:        *dir,
+		Logger:     *logger,
+		Kinds:      splitList(*kinds),
+		Caller:     *caller,
+		Message:    *msg,
+		Fields:     fields,
+		TimeLayout: *layout,
+	}
+	if fs.NArg() > 0 && q.Logger == "" {
+		q.Logger = fs.Arg(0)
+	}
+	var err error
+	if q.Since, err = parseTimeFlag(*since, now); err != nil {
+		return err
+	}
+	if q.Until, err = parseTimeFlag(*until, now); err != nil {
+		return err
+	}
+	if *level != "" {
+		var min zapcore.Level
+		if err := min.UnmarshalText([]byte(*level)); err != nil {
+			return err
+		}
+		q.Levels = query.AtLeast(min)
+	}
+
+	count := 0
+	return q.Each(func(e query.Entry) error {
+		writeLine(stdout, e.Raw, *pretty)
+		count++
+		if *limit > 0 && count >= *limit {
+			return query.ErrStop
+		}
+		return nil
+	})
+}
+
+// runPretty 将文件或标准输入中的 JSON 行转换为终端格式。
+func runPretty(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("pretty", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		return prettyStream(os.Stdin, stdout)
+	}
+	for _, name := range fs.Args() {
+		fh, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		err = prettyStream(fh, stdout)
+		fh.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func prettyStream(r io.Reader, stdout io.Writer) error {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if trimmed := strings.TrimRight(string(line), "\r\n"); trimmed != "" {
+			writeLine(stdout, []byte(trimmed), true)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// loggerUsage 汇总单个 logger 的文件信息。
+type loggerUsage struct {
+	name   string
+	kinds  map[string]bool
+	files  int
+	size   int64
+	oldest time.Time
+	newest time.Time
+}
+
+// runList 列出目录下的 logger、文件种类、文件数与磁盘占用。
+func runList(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("ls", flag.ContinueOnError)
+	dir := fs.String("dir", defaultDir, "日志目录")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	files, err := query.Query{Dir: *dir}.Files()
+	if err != nil {
+		return err
+	}
+
+	usage := make(map[string]*loggerUsage)
+	var total int64
+	for _, f := range files {
+		info, err := os.Stat(f.Path)
+		if err != nil {
+			continue
+		}
+		name := f.Logger()
+		u, ok := usage[name]
+		if !ok {
+			u = &loggerUsage{name: name, kinds: make(map[string]bool), oldest: f.Time, newest: f.Time}
+			usage[name] = u
+		}
+		u.kinds[f.Kind()] = true
+		u.files++
+		u.size += info.Size()
+		total += info.Size()
+		if f.Time.Before(u.oldest) {
+			u.oldest = f.Time
+		}
+		if f.Time.After(u.newest) {
+			u.newest = f.Time
+		}
+	}
+
+	list := make([]*loggerUsage, 0, len(usage))
+	for _, u := range usage {
+		list = append(list, u)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].size != list[j].size {
+			return list[i].size > list[j].size
+		}
+		return list[i].name < list[j].name
+	})
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "LOGGER\tKINDS\tFILES\tSIZE\tOLDEST\tNEWEST")
+	for _, u := range list {
+		kinds := make([]string, 0, len(u.kinds))
+		for k := range u.kinds {
+			kinds = append(kinds, k)
+		}
+		sort.Strings(kinds)
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\n", u.name, strings.Join(kinds, ","), u.files, humanSize(u.size),
+			u.oldest.Format(string(zlog.DATE_SEC)), u.newest.Format(string(zlog.DATE_SEC)))
+	}
+	fmt.Fprintf(tw, "TOTAL\t\t%d\t%s\t\t\n", len(files), humanSize(total))
+	return tw.Flush()
+}
+
+// runCleanup 使用与后台清理相同的规则列出将被删除的文件，不做任何修改。
+func runCleanup(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("cleanup", flag.ContinueOnError)
+	dir := fs.String("dir", defaultDir, "日志目录")
+	maxAge := fs.Int("max-age", 10*24, "保留时长（小时），与 WithMaxAge 一致")
+	maxFiles := fs.Int("max-files", 0, "每个文件前缀保留的最新文件数，与 WithMaxFiles 一致，0 表示不限制")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	paths, err := zlog.CleanupCandidates(*dir, *maxAge, *maxFiles)
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	for _, path := range paths {
+		if info, err := os.Lstat(path); err == nil {
+			total += info.Size()
+			fmt.Fprintf(stdout, "would remove %s (%s)\n", path, humanSize(info.Size()))
+		}
+	}
+	fmt.Fprintf(stdout, "%d file(s), %s would be reclaimed (dry-run)\n", len(paths), humanSize(total))
+	return nil
+}
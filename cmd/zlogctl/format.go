@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Xuzan9396/zlog"
+	"github.com/Xuzan9396/zlog/query"
+)
+
+// formatEntry 将日志条目格式化为单行终端格式：时间 等级 logger 调用方 消息 key=value...
+func formatEntry(e query.Entry) string {
+	var b strings.Builder
+	if !e.Time.IsZero() {
+		b.WriteString(e.Time.Format(string(zlog.DATE_MSEC)))
+		b.WriteByte(' ')
+	}
+	fmt.Fprintf(&b, "%-6s", e.Level.CapitalString())
+	if e.Logger != "" {
+		b.WriteString(" [")
+		b.WriteString(e.Logger)
+		b.WriteByte(']')
+	}
+	if e.Caller != "" {
+		b.WriteByte(' ')
+		b.WriteString(e.Caller)
+	}
+	b.WriteByte(' ')
+	b.WriteString(e.Message)
+
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, " %s=%v", k, e.Fields[k])
+	}
+	if e.Stacktrace != "" {
+		b.WriteByte('\n')
+		b.WriteString(e.Stacktrace)
+	}
+	return b.String()
+}
+
+// writeLine 按 pretty 设置输出一行原始 JSON，无法解析的行原样输出。
+func writeLine(w io.Writer, raw []byte, pretty bool) {
+	if pretty {
+		if e, err := query.Decode(raw); err == nil {
+			fmt.Fprintln(w, formatEntry(e))
+			return
+		}
+	}
+	fmt.Fprintln(w, string(raw))
+}
+
+// parseTimeFlag 解析时间参数：相对时长（2h、30m 表示此前）、日期或完整时间，按 ZLOG_TZ 时区解释。
+func parseTimeFlag(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	layouts := []string{time.RFC3339, string(zlog.DATE_MSEC), string(zlog.DATE_SEC), "2006-01-02 15:04", "2006-01-02"}
+	for _, layout := range layouts {
+		if ts, err := time.ParseInLocation(layout, value, zlog.Location()); err == nil {
+			return ts, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", value)
+}
+
+// fieldFlags 收集可重复的 -field key=value 参数。
+type fieldFlags map[string]string
+
+func (f fieldFlags) String() string {
+	pairs := make([]string, 0, len(f))
+	for k, v := range f {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (f fieldFlags) Set(value string) error {
+	idx := strings.Index(value, "=")
+	if idx <= 0 {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	f[value[:idx]] = value[idx+1:]
+	return nil
+}
+
+// humanSize 将字节数格式化为 KB/MB/GB。
+func humanSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,67 @@
+// Command zlogctl 按 zlog 的目录布局查看与维护日志：跨切割 tail、按条件 grep、
+// 美化 JSON 行、列出 logger 磁盘占用，以及以 dry-run 方式预览清理结果。
+//
+// 用法：
+//
+//	zlogctl tail    [-dir logs] [-kind info] [-n 10] [-f] [-pretty] <logger>
+//	zlogctl grep    [-dir logs] [-logger api] [-level warn] [-since 2h] [-field k=v] ...
+//	zlogctl pretty  [file ...]
+//	zlogctl ls      [-dir logs]
+//	zlogctl cleanup [-dir logs] [-max-age 240] [-max-files 0]
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+const defaultDir = "logs"
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string, stdout io.Writer) error
+}
+
+var commands = []command{
+	{"tail", "跨切割跟踪某个 logger 的日志文件", runTail},
+	{"grep", "按等级、时间、调用方与字段过滤日志", runGrep},
+	{"pretty", "将 JSON 日志行转换为易读的终端格式", runPretty},
+	{"ls", "列出 logger 及其文件数量与磁盘占用", runList},
+	{"cleanup", "以 dry-run 方式列出清理将删除的文件", runCleanup},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+
+	name := os.Args[1]
+	for _, cmd := range commands {
+		if cmd.name == name {
+			if err := cmd.run(os.Args[2:], os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "zlogctl %s: %v\n", name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	if name != "-h" && name != "--help" && name != "help" {
+		fmt.Fprintf(os.Stderr, "zlogctl: unknown command %q\n\n", name)
+	}
+	usage(os.Stderr)
+	os.Exit(2)
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: zlogctl <command> [flags]")
+	fmt.Fprintln(w)
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "run 'zlogctl <command> -h' for command flags")
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Xuzan9396/zlog"
+)
+
+func appendFile(t *testing.T, path, content string) {
+	t.Helper()
+	fh, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fh.Close()
+	if _, err := fh.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPrettyFormat(t *testing.T) {
+	var out bytes.Buffer
+	in := strings.NewReader(`{"level":"warn","time":"2025-01-15 09:00:00","line":"api/limit.go:30","message":"slow","user":"u2","cost":12}` + "\nplain text\n")
+	if err := prettyStream(in, &out); err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Split(strings.TrimSpace(out.String()), "\n")
+	want := "2025-01-15 09:00:00.000 WARN   api/limit.go:30 slow cost=12 user=u2"
+	if len(got) != 2 || got[0] != want || got[1] != "plain text" {
+		t.Fatalf("unexpected pretty output:\n%s", out.String())
+	}
+}
+
+func TestParseTimeFlag(t *testing.T) {
+	now := time.Date(2025, 1, 15, 12, 0, 0, 0, zlog.Location())
+	cases := map[string]time.Time{
+		"2h":                  now.Add(-2 * time.Hour),
+		"2025-01-14":          time.Date(2025, 1, 14, 0, 0, 0, 0, zlog.Location()),
+		"2025-01-14 08:30:00": time.Date(2025, 1, 14, 8, 30, 0, 0, zlog.Location()),
+	}
+	for value, want := range cases {
+		got, err := parseTimeFlag(value, now)
+		if err != nil || !got.Equal(want) {
+			t.Fatalf("%s: expected %s, got %s (%v)", value, want, got, err)
+		}
+	}
+	if _, err := parseTimeFlag("yesterday", now); err == nil {
+		t.Fatal("expected error for invalid time")
+	}
+}
+
+// TestParseTimeFlagLocation 在 ZLOG_TZ 与本地时区不同的子进程中确认时间参数按 ZLOG_TZ 解析
+func TestParseTimeFlagLocation(t *testing.T) {
+	if os.Getenv("ZLOG_TZ") != "Asia/Tokyo" {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestParseTimeFlagLocation$")
+		cmd.Env = append(os.Environ(), "ZLOG_TZ=Asia/Tokyo", "TZ=UTC")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("subprocess failed: %v\n%s", err, out)
+		}
+		return
+	}
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip(err)
+	}
+	got, err := parseTimeFlag("2025-01-14 08:30:00", time.Now())
+	if want := time.Date(2025, 1, 14, 8, 30, 0, 0, tokyo); err != nil || !got.Equal(want) {
+		t.Fatalf("expected %s, got %s (%v)", want, got, err)
+	}
+}
+
+func TestTailFollowsRotation(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "api_info2025-01-15.log")
+	appendFile(t, first, "{\"level\":\"info\",\"message\":\"a\"}\n{\"level\":\"info\",\"message\":\"b\"}\n")
+
+	var out bytes.Buffer
+	if err := runTail([]string{"-dir", dir, "-n", "1", "api"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "{\"level\":\"info\",\"message\":\"b\"}\n" {
+		t.Fatalf("unexpected tail output %q", got)
+	}
+
+	out.Reset()
+	f := &follower{out: &out}
+	f.query.Dir = dir
+	f.query.Logger = "api"
+	f.query.Kinds = []string{"info"}
+	if err := f.start(0); err != nil {
+		t.Fatal(err)
+	}
+
+	// 半行不输出，补全后输出
+	appendFile(t, first, "{\"level\":\"info\",\"message\":\"c\"")
+	if err := f.poll(); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != 0 {
+		t.Fatalf("partial line should be buffered, got %q", out.String())
+	}
+	appendFile(t, first, "}\n")
+
+	// 切割：新文件出现后，先读完旧文件再切换
+	second := filepath.Join(dir, "api_info2025-01-16.log")
+	appendFile(t, second, "{\"level\":\"info\",\"message\":\"d\"}\n")
+	if err := f.poll(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "{\"level\":\"info\",\"message\":\"c\"}\n{\"level\":\"info\",\"message\":\"d\"}\n"
+	if got := out.String(); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if f.path != second {
+		t.Fatalf("expected follower to switch to %s, got %s", second, f.path)
+	}
+}
+
+func TestListAndCleanup(t *testing.T) {
+	dir := t.TempDir()
+	appendFile(t, filepath.Join(dir, "api_info2020-01-01.log"), "old\n")
+	appendFile(t, filepath.Join(dir, "api_info"+time.Now().Format("2006-01-02")+".log"), "new\n")
+	appendFile(t, filepath.Join(dir, "order_api_error"+time.Now().Format("2006-01-02")+".log"), "err\n")
+
+	var out bytes.Buffer
+	if err := runList([]string{"-dir", dir}, &out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"api ", "order_api ", "TOTAL"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("ls output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := runCleanup([]string{"-dir", dir, "-max-age", "24"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "api_info2020-01-01.log") || !strings.Contains(out.String(), "1 file(s)") {
+		t.Fatalf("unexpected cleanup output:\n%s", out.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "api_info2020-01-01.log")); err != nil {
+		t.Fatalf("dry-run must not delete files: %v", err)
+	}
+
+	// -max-files 与 WithMaxFiles 一致：每个前缀只保留最新的文件
+	appendFile(t, filepath.Join(dir, "api_info"+time.Now().AddDate(0, 0, -1).Format("2006-01-02")+".log"), "yesterday\n")
+	out.Reset()
+	if err := runCleanup([]string{"-dir", dir, "-max-age", "240000", "-max-files", "1"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "2 file(s)") || strings.Contains(out.String(), "api_info"+time.Now().Format("2006-01-02")) {
+		t.Fatalf("unexpected max-files output:\n%s", out.String())
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/Xuzan9396/zlog/query"
+)
+
+// runTail 输出某个 logger 最新文件的末尾若干行，-f 时持续跟踪并在切割后自动切换到新文件。
+func runTail(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("tail", flag.ContinueOnError)
+	dir := fs.String("dir", defaultDir, "日志目录")
+	logger := fs.String("logger", "", "logger 名称（也可作为位置参数）")
+	kind := fs.String("kind", "info", "文件种类，例如 info、error、debug")
+	lines := fs.Int("n", 10, "先输出的末尾行数")
+	followFlag := fs.Bool("f", false, "持续跟踪新写入的日志")
+	interval := fs.Duration("interval", 500*time.Millisecond, "跟踪时的轮询间隔")
+	pretty := fs.Bool("pretty", false, "以终端格式输出")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *logger == "" {
+		*logger = fs.Arg(0)
+	}
+	if *logger == "" {
+		return errors.New("logger name is required")
+	}
+
+	f := &follower{
+		query:  query.Query{Dir: *dir, Logger: *logger, Kinds: []string{*kind}},
+		out:    stdout,
+		pretty: *pretty,
+	}
+	if err := f.start(*lines); err != nil {
+		return err
+	}
+	if !*followFlag {
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := f.poll(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// follower 跟踪 logger 的最新日志文件，按读取偏移输出新增的完整行。
+type follower struct {
+	query   query.Query
+	out     io.Writer
+	pretty  bool
+	path    string
+	offset  int64
+	partial []byte
+}
+
+// latest 返回当前最新的未压缩文件路径。
+func (f *follower) latest() (string, error) {
+	files, err := f.query.Files()
+	if err != nil {
+		return "", err
+	}
+	for i := len(files) - 1; i >= 0; i-- {
+		if !files[i].Compressed {
+			return files[i].Path, nil
+		}
+	}
+	return "", nil
+}
+
+// start 定位最新文件并输出末尾 n 行，之后从文件末尾开始跟踪。
+func (f *follower) start(n int) error {
+	path, err := f.latest()
+	if err != nil || path == "" {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	f.path = path
+	f.offset = int64(len(data))
+
+	all := bytes.Split(bytes.TrimRight(data, "\n"), []byte("\n"))
+	if len(all) == 1 && len(all[0]) == 0 {
+		return nil
+	}
+	if n >= 0 && len(all) > n {
+		all = all[len(all)-n:]
+	}
+	for _, line := range all {
+		writeLine(f.out, line, f.pretty)
+	}
+	return nil
+}
+
+// poll 读取当前文件新增内容；出现更新的文件时先读完旧文件再切换。
+func (f *follower) poll() error {
+	if f.path != "" {
+		if err := f.drain(); err != nil {
+			return err
+		}
+	}
+
+	next, err := f.latest()
+	if err != nil {
+		return err
+	}
+	if next != "" && next != f.path {
+		f.path = next
+		f.offset = 0
+		f.partial = nil
+		return f.drain()
+	}
+	return nil
+}
+
+// drain 从偏移处读到文件末尾，只输出以换行结尾的完整行。
+func (f *follower) drain() error {
+	fh, err := os.Open(f.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	info, err := fh.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() < f.offset {
+		// 文件被截断，从头读取
+		f.offset = 0
+		f.partial = nil
+	}
+	if _, err := fh.Seek(f.offset, io.SeekStart); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(fh)
+	if err != nil {
+		return err
+	}
+	f.offset += int64(len(data))
+
+	data = append(f.partial, data...)
+	for {
+		idx := bytes.IndexByte(data, '\n')
+		if idx < 0 {
+			break
+		}
+		if line := bytes.TrimRight(data[:idx], "\r"); len(line) > 0 {
+			writeLine(f.out, line, f.pretty)
+		}
+		data = data[idx+1:]
+	}
+	f.partial = append([]byte(nil), data...)
+	return nil
+}
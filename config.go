@@ -1,6 +1,7 @@
 package zlog
 
 import (
+	"fmt"
 	"os"
 	"strings"
 	"time"
@@ -39,18 +40,64 @@ const (
 
 // Config 聚合日志系统运行所需的全部配置。
 type Config struct {
-	WithMaxAge        int
-	WithRotationTime  int
-	Env               Env
-	Level             zapcore.Level
-	formDate          EnvDate
-	levelOverride     bool
-	DefaultLoggerName string
-	ErrorLoggerName   string
-	ConsoleOnly       bool          // 仅输出到终端，不写入文件
-	AutoCleanup       bool          // 是否启用后台自动清理（默认 true）
-	CleanupInterval   time.Duration // 清理间隔（默认 24 小时）
-	LogDir            string        // 日志目录根路径
+	WithMaxAge          int
+	WithRotationTime    int
+	RotationPeriod      time.Duration // 切割周期，非零时优先于 WithRotationTime，支持小时以下粒度
+	MaxFiles            int           // 每个文件前缀保留的最新带日期文件数，0 表示不限制
+	Env                 Env
+	Level               zapcore.Level
+	formDate            EnvDate
+	levelOverride       bool
+	envLevel            zapcore.Level // ZLOG_LEVEL 中的全局等级
+	envLevelSet         bool
+	DefaultLoggerName   string
+	ErrorLoggerName     string
+	ConsoleOnly         bool                     // 仅输出到终端，不写入文件
+	SplitLevels         bool                     // 按等级拆分文件，替代单一的 <name>_info.log
+	LevelFiles          []LevelFile              // 等级拆分方案，为空时使用 DefaultLevelFiles
+	PerLoggerErrorFile  bool                     // 为每个 logger 额外写入 <name>_error.log，共享错误文件照常写入
+	LoggerErrorFiles    map[string]bool          // 按 logger 名称覆盖 PerLoggerErrorFile
+	LoggerLevels        map[string]zapcore.Level // 按点分名称覆盖等级，后代 logger 继承最近祖先的设置
+	SharedFiles         map[string]bool          // 为 true 时后代 logger 写入该 logger 的文件，最近祖先的设置生效
+	AutoCleanup         bool                     // 是否启用后台自动清理（默认 true）
+	CleanupInterval     time.Duration            // 清理间隔（默认 24 小时，不大于 0 时同样使用默认值）
+	CleanupAt           string                   // 每天固定本地时间清理，格式 "HH:MM"，优先于 CleanupInterval
+	CleanupCron         string                   // 5 段 cron 表达式（分 时 日 月 周），优先于 CleanupAt
+	CleanupJitter       time.Duration            // 每次清理前的随机延迟上限，避免集群同时访问共享存储
+	CleanupOnStart      bool                     // 清理任务启动时立即执行一次
+	CleanupCallback     func(CleanupReport)      // 每次后台/手动清理完成后回调
+	CleanupLogName      string                   // 非空时将清理结果写入该名称的 logger
+	Archiver            Archiver                 // 非空时过期日志先归档再删除
+	ArchiveTimeout      time.Duration            // 归档单个文件的超时，0 表示 5 分钟；超时的文件保留到下一轮清理
+	LogDir              string                   // 日志目录根路径
+	ErrorHandler        func(WriteError)         // 日志文件打开/写入失败时回调，为空时打印到 stderr
+	WriteFallback       WriteFallback            // 日志文件不可写时的兜底策略（默认 stderr）
+	ReopenBackoff       time.Duration            // 失败后重新打开文件的初始退避时间（默认 1 秒，每次失败翻倍）
+	ReopenMaxBackoff    time.Duration            // 重新打开文件的最大退避时间（默认 1 分钟）
+	FallbackBufferSize  int                      // FallbackBuffer 缓存的最大条目数（默认 1000）
+	EmergencyFreeBytes  int64                    // 磁盘已满时紧急清理至少释放的字节数，0 表示不做紧急清理（默认 64MB）
+	Sampling            *Sampling                // 非空时对高频重复日志采样
+	MaxLoggers          int                      // 同时打开文件的 logger 数上限，超出时淘汰最久未使用的，0 表示不限制
+	LoggerIdleTimeout   time.Duration            // logger 超过该时间未写日志时关闭其文件，0 表示不淘汰
+	MaxLoggerNameLength int                      // logger 名称的最大字节数（默认 DefaultMaxLoggerNameLength）
+	LoggerNameMapping   bool                     // LoggerE 将不安全字符替换为 '_' 而不是返回错误
+}
+
+// LevelFile 描述按等级拆分时单个文件覆盖的等级区间（闭区间）。
+type LevelFile struct {
+	Name string        // 文件后缀，例如 "debug" 对应 <logger>_debug.log
+	Min  zapcore.Level // 最低等级（包含）
+	Max  zapcore.Level // 最高等级（包含）
+}
+
+// DefaultLevelFiles 返回默认的拆分方案：debug、info、warn 各一个文件，error 及以上合并为一个文件。
+func DefaultLevelFiles() []LevelFile {
+	return []LevelFile{
+		{Name: "debug", Min: zapcore.DebugLevel, Max: zapcore.DebugLevel},
+		{Name: "info", Min: zapcore.InfoLevel, Max: zapcore.InfoLevel},
+		{Name: "warn", Min: zapcore.WarnLevel, Max: zapcore.WarnLevel},
+		{Name: "error", Min: zapcore.ErrorLevel, Max: zapcore.FatalLevel},
+	}
 }
 
 // LogOption 通过函数式选项修改配置。
@@ -59,6 +106,8 @@ type LogOption func(*Config)
 const (
 	defaultPrefixEnv = "ZLOG_FILE_PREFIX"
 	defaultBaseName  = "log"
+
+	defaultCleanupInterval = 24 * time.Hour // 默认每 24 小时清理一次
 )
 
 var envLevelMap = map[Env]zapcore.Level{
@@ -72,31 +121,64 @@ var envLevelMap = map[Env]zapcore.Level{
 	ENV_FATAL:  zapcore.FatalLevel,
 }
 
-// newDefaultConfig 返回默认配置。
+// newDefaultConfig 返回默认配置，并应用 ZLOG_* 环境变量；无法解析的变量提示后忽略。
 func newDefaultConfig() Config {
-	prefix := strings.TrimSpace(os.Getenv(defaultPrefixEnv))
-	if prefix == "" {
-		prefix = defaultBaseName
+	cfg := builtinConfig()
+	for _, err := range applyEnv(&cfg, os.LookupEnv) {
+		fmt.Fprintf(os.Stderr, "%v, ignored\n", err)
 	}
+	return cfg
+}
+
+// builtinConfig 返回不受环境变量影响的内置默认配置。
+func builtinConfig() Config {
+	prefix := defaultBaseName
 	errorName := prefix + "_error"
-	dir := defaultLogsDir()
+	dir := workingLogsDir()
 
 	return Config{
-		WithMaxAge:        10 * 24,
-		WithRotationTime:  24,
-		Env:               ENV_PRO,
-		Level:             resolveLevel(ENV_PRO),
-		DefaultLoggerName: prefix,
-		ErrorLoggerName:   errorName,
-		formDate:          DATE_SEC,
-		AutoCleanup:       true,           // 默认启用自动清理
-		CleanupInterval:   24 * time.Hour, // 默认每 24 小时清理一次
-		LogDir:            dir,
+		WithMaxAge:         10 * 24,
+		WithRotationTime:   24,
+		Env:                ENV_PRO,
+		Level:              resolveLevel(ENV_PRO),
+		DefaultLoggerName:  prefix,
+		ErrorLoggerName:    errorName,
+		formDate:           DATE_SEC,
+		AutoCleanup:        true, // 默认启用自动清理
+		CleanupInterval:    defaultCleanupInterval,
+		ReopenBackoff:      defaultReopenBackoff,
+		ReopenMaxBackoff:   defaultReopenMaxBackoff,
+		EmergencyFreeBytes: defaultEmergencyFreeBytes,
+		LogDir:             dir,
 	}
 }
 
 // cloneConfig 生成配置副本，避免外部修改内部状态。
 func cloneConfig(cfg Config) Config {
+	if cfg.LevelFiles != nil {
+		cfg.LevelFiles = append([]LevelFile(nil), cfg.LevelFiles...)
+	}
+	if cfg.LoggerErrorFiles != nil {
+		overrides := make(map[string]bool, len(cfg.LoggerErrorFiles))
+		for name, enable := range cfg.LoggerErrorFiles {
+			overrides[name] = enable
+		}
+		cfg.LoggerErrorFiles = overrides
+	}
+	if cfg.LoggerLevels != nil {
+		levels := make(map[string]zapcore.Level, len(cfg.LoggerLevels))
+		for name, level := range cfg.LoggerLevels {
+			levels[name] = level
+		}
+		cfg.LoggerLevels = levels
+	}
+	if cfg.SharedFiles != nil {
+		shared := make(map[string]bool, len(cfg.SharedFiles))
+		for name, enable := range cfg.SharedFiles {
+			shared[name] = enable
+		}
+		cfg.SharedFiles = shared
+	}
 	return cfg
 }
 
@@ -111,6 +193,15 @@ func WithMaxAge(withMaxAge int) LogOption {
 func WithRotationTime(withRotationTime int) LogOption {
 	return func(cfg *Config) {
 		cfg.WithRotationTime = withRotationTime
+		cfg.RotationPeriod = 0
+	}
+}
+
+// WithRotationPeriod 以 time.Duration 设置切割周期，支持小时、15 分钟等小于一天的粒度。
+// 小于一天的周期会在文件名中追加小时/分钟，例如 api_info2025-01-15-13.log。
+func WithRotationPeriod(period time.Duration) LogOption {
+	return func(cfg *Config) {
+		cfg.RotationPeriod = period
 	}
 }
 
@@ -159,6 +250,152 @@ func WithConsoleOnly(consoleOnly bool) LogOption {
 	}
 }
 
+// WithSplitLevels 设置是否按等级拆分文件（api_debug.log、api_info.log、api_warn.log、api_error.log）。
+func WithSplitLevels(enable bool) LogOption {
+	return func(cfg *Config) {
+		cfg.SplitLevels = enable
+	}
+}
+
+// WithLevelFiles 开启按等级拆分并指定拆分方案，未传参数时使用 DefaultLevelFiles。
+func WithLevelFiles(files ...LevelFile) LogOption {
+	return func(cfg *Config) {
+		cfg.SplitLevels = true
+		cfg.LevelFiles = append([]LevelFile(nil), files...)
+	}
+}
+
+// WithPerLoggerErrorFile 设置是否为每个 logger 单独输出错误文件（如 payment_error.log）。
+func WithPerLoggerErrorFile(enable bool) LogOption {
+	return func(cfg *Config) {
+		cfg.PerLoggerErrorFile = enable
+	}
+}
+
+// WithLoggerErrorFile 针对单个 logger 开启或关闭独立错误文件，优先于 WithPerLoggerErrorFile。
+func WithLoggerErrorFile(name string, enable bool) LogOption {
+	return func(cfg *Config) {
+		name = normalizeName(name)
+		if name == "" {
+			return
+		}
+		// 先复制再写入，避免修改其他配置副本共享的 map
+		overrides := make(map[string]bool, len(cfg.LoggerErrorFiles)+1)
+		for k, v := range cfg.LoggerErrorFiles {
+			overrides[k] = v
+		}
+		overrides[name] = enable
+		cfg.LoggerErrorFiles = overrides
+	}
+}
+
+// WithLoggerLevel 为点分名称及其全部后代设置固定等级，例如 WithLoggerLevel("order.payment", zapcore.DebugLevel)
+// 同时作用于 order.payment.refund；后代可以再单独覆盖，未覆盖的 logger 跟随全局等级。
+func WithLoggerLevel(name string, level zapcore.Level) LogOption {
+	return func(cfg *Config) {
+		name = normalizeName(name)
+		if name == "" {
+			return
+		}
+		levels := make(map[string]zapcore.Level, len(cfg.LoggerLevels)+1)
+		for k, v := range cfg.LoggerLevels {
+			levels[k] = v
+		}
+		levels[name] = level
+		cfg.LoggerLevels = levels
+	}
+}
+
+// WithSharedFile 设置 name 的后代 logger 是否写入 name 的文件（如 order.payment.refund 写入 order_info.log），
+// 后代可以用 false 改回写自己的文件。共享文件中的日志带 f 字段区分来源。
+func WithSharedFile(name string, shared bool) LogOption {
+	return func(cfg *Config) {
+		name = normalizeName(name)
+		if name == "" {
+			return
+		}
+		overrides := make(map[string]bool, len(cfg.SharedFiles)+1)
+		for k, v := range cfg.SharedFiles {
+			overrides[k] = v
+		}
+		overrides[name] = shared
+		cfg.SharedFiles = overrides
+	}
+}
+
+// WithMaxFiles 设置每个文件前缀（如 api_info）最多保留的带日期文件数，由清理任务执行，0 表示不限制。
+func WithMaxFiles(n int) LogOption {
+	return func(cfg *Config) {
+		cfg.MaxFiles = n
+	}
+}
+
+// WithMaxLoggers 设置同时打开文件的 logger 数上限，超出时刷新并关闭最久未使用的 logger 的文件，
+// 已获取的 logger 仍然可用，下次写日志时自动重新打开。适合按租户等高基数名称创建 logger 的场景。
+func WithMaxLoggers(n int) LogOption {
+	return func(cfg *Config) {
+		cfg.MaxLoggers = n
+	}
+}
+
+// WithLoggerIdleTimeout 设置 logger 空闲多久后关闭其文件，下次写日志时自动重新打开，0 表示不淘汰。
+func WithLoggerIdleTimeout(timeout time.Duration) LogOption {
+	return func(cfg *Config) {
+		cfg.LoggerIdleTimeout = timeout
+	}
+}
+
+// WithMaxLoggerNameLength 设置 logger 名称的最大字节数，超出时 LoggerE 返回错误，Logger 截断名称。
+func WithMaxLoggerNameLength(n int) LogOption {
+	return func(cfg *Config) {
+		cfg.MaxLoggerNameLength = n
+	}
+}
+
+// WithLoggerNameMapping 设置 LoggerE 是否将不安全字符映射为 '_'（并截断超长名称）而不是返回错误。
+func WithLoggerNameMapping(enable bool) LogOption {
+	return func(cfg *Config) {
+		cfg.LoggerNameMapping = enable
+	}
+}
+
+// WithErrorHandler 设置日志文件打开或写入失败时的回调，回调中可以继续写日志。
+func WithErrorHandler(handler func(WriteError)) LogOption {
+	return func(cfg *Config) {
+		cfg.ErrorHandler = handler
+	}
+}
+
+// WithWriteFallback 设置日志文件不可写时的兜底策略：FallbackStderr 或 FallbackDiscard。
+func WithWriteFallback(fallback WriteFallback) LogOption {
+	return func(cfg *Config) {
+		cfg.WriteFallback = fallback
+	}
+}
+
+// WithFallbackBuffer 设置日志文件不可写时将条目缓存在内存环形缓冲区（最多 size 条），文件恢复后先写回缓存。
+func WithFallbackBuffer(size int) LogOption {
+	return func(cfg *Config) {
+		cfg.WriteFallback = FallbackBuffer
+		cfg.FallbackBufferSize = size
+	}
+}
+
+// WithEmergencyCleanup 设置磁盘已满时紧急清理需要释放的字节数，按时间从旧到新删除，0 表示关闭。
+func WithEmergencyCleanup(freeBytes int64) LogOption {
+	return func(cfg *Config) {
+		cfg.EmergencyFreeBytes = freeBytes
+	}
+}
+
+// WithReopenBackoff 设置失败后重新打开日志文件的退避时间，从 min 开始每次失败翻倍，最多 max。
+func WithReopenBackoff(min, max time.Duration) LogOption {
+	return func(cfg *Config) {
+		cfg.ReopenBackoff = min
+		cfg.ReopenMaxBackoff = max
+	}
+}
+
 // WithAutoCleanup 设置是否启用后台自动清理。
 func WithAutoCleanup(enable bool) LogOption {
 	return func(cfg *Config) {
@@ -170,6 +407,66 @@ func WithAutoCleanup(enable bool) LogOption {
 func WithCleanupInterval(interval time.Duration) LogOption {
 	return func(cfg *Config) {
 		cfg.CleanupInterval = interval
+		cfg.CleanupAt = ""
+		cfg.CleanupCron = ""
+	}
+}
+
+// WithCleanupAt 设置每天固定的本地清理时间，例如 "03:00"。
+func WithCleanupAt(hhmm string) LogOption {
+	return func(cfg *Config) {
+		cfg.CleanupAt = hhmm
+		cfg.CleanupCron = ""
+	}
+}
+
+// WithCleanupCron 使用 5 段 cron 表达式（分 时 日 月 周）调度清理，例如 "30 3 * * 1-5"。
+func WithCleanupCron(expr string) LogOption {
+	return func(cfg *Config) {
+		cfg.CleanupCron = expr
+		cfg.CleanupAt = ""
+	}
+}
+
+// WithCleanupJitter 为每次清理叠加 [0, jitter) 的随机延迟。
+func WithCleanupJitter(jitter time.Duration) LogOption {
+	return func(cfg *Config) {
+		cfg.CleanupJitter = jitter
+	}
+}
+
+// WithCleanupOnStart 设置清理任务启动时是否立即执行一次清理。
+func WithCleanupOnStart(enable bool) LogOption {
+	return func(cfg *Config) {
+		cfg.CleanupOnStart = enable
+	}
+}
+
+// WithCleanupCallback 设置清理完成后的回调，可用于上报或自定义记录清理结果。
+func WithCleanupCallback(fn func(CleanupReport)) LogOption {
+	return func(cfg *Config) {
+		cfg.CleanupCallback = fn
+	}
+}
+
+// WithCleanupLogger 指定记录清理结果的日志通道，例如 "zlog_cleanup"。
+func WithCleanupLogger(name string) LogOption {
+	return func(cfg *Config) {
+		cfg.CleanupLogName = normalizeName(name)
+	}
+}
+
+// WithArchiver 设置过期日志的归档器，只有归档成功的文件才会被删除。
+func WithArchiver(archiver Archiver) LogOption {
+	return func(cfg *Config) {
+		cfg.Archiver = archiver
+	}
+}
+
+// WithArchiveTimeout 设置归档单个文件的超时，避免上传卡住时清理无法结束。
+func WithArchiveTimeout(timeout time.Duration) LogOption {
+	return func(cfg *Config) {
+		cfg.ArchiveTimeout = timeout
 	}
 }
 
@@ -183,17 +480,28 @@ func WithLogDir(dir string) LogOption {
 	}
 }
 
+// loggerErrorFileEnabled 判断指定 logger 是否需要独立错误文件。
+func (cfg Config) loggerErrorFileEnabled(name string) bool {
+	if enable, ok := cfg.LoggerErrorFiles[name]; ok {
+		return enable
+	}
+	return cfg.PerLoggerErrorFile
+}
+
 func normalizeName(name string) string {
 	return strings.TrimSpace(name)
 }
 
-// applyOptions 应用可选参数，并在未覆盖等级时同步环境默认等级。
+// applyOptions 先应用 cfg.Env 注册的 profile，再应用可选参数，并在未覆盖等级时同步环境默认等级。
 func applyOptions(cfg *Config, options ...LogOption) {
+	for _, option := range profileOptions(cfg.Env) {
+		option(cfg)
+	}
 	for _, option := range options {
 		option(cfg)
 	}
 	if !cfg.levelOverride {
-		cfg.Level = resolveLevel(cfg.Env)
+		cfg.Level = cfg.baseLevel()
 	}
 	if cfg.DefaultLoggerName == "" {
 		cfg.DefaultLoggerName = defaultBaseName
@@ -201,11 +509,26 @@ func applyOptions(cfg *Config, options ...LogOption) {
 	if cfg.ErrorLoggerName == "" {
 		cfg.ErrorLoggerName = cfg.DefaultLoggerName + "_error"
 	}
+	// 默认与错误前缀同样用作文件名，来自环境变量时也不能越出 LogDir
+	if ValidateLoggerName(cfg.DefaultLoggerName, cfg.maxLoggerNameLength()) != nil {
+		cfg.DefaultLoggerName = SanitizeLoggerName(cfg.DefaultLoggerName, cfg.maxLoggerNameLength())
+	}
+	if ValidateLoggerName(cfg.ErrorLoggerName, cfg.maxLoggerNameLength()) != nil {
+		cfg.ErrorLoggerName = SanitizeLoggerName(cfg.ErrorLoggerName, cfg.maxLoggerNameLength())
+	}
 	if cfg.LogDir == "" {
 		cfg.LogDir = defaultLogsDir()
 	}
 }
 
+// baseLevel 返回没有 WithLevel/SetLevel 时的等级：ZLOG_LEVEL 的全局等级优先于 Env 映射。
+func (cfg Config) baseLevel() zapcore.Level {
+	if cfg.envLevelSet {
+		return cfg.envLevel
+	}
+	return resolveLevel(cfg.Env)
+}
+
 // resolveLevel 将 Env 映射到对应 zap 等级，默认返回 Info。
 func resolveLevel(env Env) zapcore.Level {
 	if level, ok := envLevelMap[env]; ok {
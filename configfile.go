@@ -0,0 +1,403 @@
+package zlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
+)
+
+// configReloadDelay 是配置文件变化后等待写入完成的时间，合并编辑器的多次写入。
+const configReloadDelay = 100 * time.Millisecond
+
+// FileConfig 是 YAML/JSON 配置文件的内容。省略的字段保留当前配置；出现的 map 整体替换对应配置。
+//
+//	env: info
+//	level: debug
+//	levels: {order: warn, order.payment: debug}
+//	log_dir: /var/log/app
+//	max_age: 10d
+//	max_files: 30
+//	rotation: 1h
+//	console_only: false
+//	sinks:
+//	  split_levels: true
+//	  per_logger_error_file: true
+//	  shared_files: {order: true}
+type FileConfig struct {
+	Env         string            `yaml:"env" json:"env"`
+	Level       string            `yaml:"level" json:"level"`               // 覆盖 env 对应的等级
+	Levels      map[string]string `yaml:"levels" json:"levels"`             // 点分名称的等级覆盖，见 WithLoggerLevel
+	LogDir      string            `yaml:"log_dir" json:"log_dir"`           // 日志目录
+	MaxAge      string            `yaml:"max_age" json:"max_age"`           // 保留时长，如 240h、10d，最小 1h
+	MaxFiles    *int              `yaml:"max_files" json:"max_files"`       // 每个文件前缀保留的文件数，0 表示不限制
+	Rotation    string            `yaml:"rotation" json:"rotation"`         // 切割周期，如 1h、24h、1d
+	ConsoleOnly *bool             `yaml:"console_only" json:"console_only"` // 仅输出到终端
+	Sinks       *SinksConfig      `yaml:"sinks" json:"sinks"`               // 文件输出方式
+}
+
+// SinksConfig 描述文件输出方式。
+type SinksConfig struct {
+	SplitLevels        *bool           `yaml:"split_levels" json:"split_levels"`
+	PerLoggerErrorFile *bool           `yaml:"per_logger_error_file" json:"per_logger_error_file"`
+	SharedFiles        map[string]bool `yaml:"shared_files" json:"shared_files"` // 见 WithSharedFile
+}
+
+// ConfigFileError 汇总配置文件中的全部问题，出现问题时不会应用其中任何设置。
+type ConfigFileError struct {
+	Path     string
+	Problems []string
+}
+
+// Error 实现 error 接口。
+func (e *ConfigFileError) Error() string {
+	return fmt.Sprintf("zlog: invalid config %s: %s", e.Path, strings.Join(e.Problems, "; "))
+}
+
+// LoadConfig 读取并校验配置文件，.json 按 JSON 解析，其他扩展名按 YAML 解析，未知字段视为错误。
+func LoadConfig(path string) (*FileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseConfigFile(path, data)
+}
+
+// parseConfigFile 解析并校验配置内容。
+func parseConfigFile(path string, data []byte) (*FileConfig, error) {
+	fc := &FileConfig{}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(fc); err != nil {
+			return nil, fmt.Errorf("zlog: parse config %s: %w", path, err)
+		}
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		// 空文件视为没有任何设置
+		if err := dec.Decode(fc); err != nil && len(bytes.TrimSpace(data)) > 0 {
+			return nil, fmt.Errorf("zlog: parse config %s: %w", path, err)
+		}
+	}
+	if _, err := fc.options(path); err != nil {
+		return nil, err
+	}
+	return fc, nil
+}
+
+// options 校验配置并转换为 LogOption，发现任何问题时返回 *ConfigFileError。
+func (fc *FileConfig) options(path string) ([]LogOption, error) {
+	var opts []LogOption
+	var problems []string
+	problem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if fc.Env != "" {
+		if !knownEnv(Env(fc.Env)) {
+			problem("unknown env %q", fc.Env)
+		}
+	}
+	if fc.Level != "" {
+		if level, err := parseLevel(fc.Level); err != nil {
+			problem("level: %v", err)
+		} else {
+			opts = append(opts, WithLevel(level))
+		}
+	}
+	if fc.Levels != nil {
+		levels := make(map[string]zapcore.Level, len(fc.Levels))
+		for _, name := range sortedKeys(fc.Levels) {
+			if err := ValidateLoggerName(name, 0); err != nil {
+				problem("levels: %v", err)
+				continue
+			}
+			level, err := parseLevel(fc.Levels[name])
+			if err != nil {
+				problem("levels.%s: %v", name, err)
+				continue
+			}
+			levels[name] = level
+		}
+		opts = append(opts, func(cfg *Config) { cfg.LoggerLevels = levels })
+	}
+	if fc.LogDir != "" {
+		opts = append(opts, WithLogDir(fc.LogDir))
+	}
+	if fc.MaxAge != "" {
+		if d, err := parseConfigDuration(fc.MaxAge); err != nil || d < time.Hour {
+			problem("max_age %q: want a duration of at least 1h, such as 240h or 10d", fc.MaxAge)
+		} else {
+			opts = append(opts, WithMaxAge(int(d/time.Hour)))
+		}
+	}
+	if fc.MaxFiles != nil {
+		if *fc.MaxFiles < 0 {
+			problem("max_files must not be negative")
+		} else {
+			opts = append(opts, WithMaxFiles(*fc.MaxFiles))
+		}
+	}
+	if fc.Rotation != "" {
+		if d, err := parseConfigDuration(fc.Rotation); err != nil || d < time.Minute {
+			problem("rotation %q: want a duration of at least 1m, such as 1h or 1d", fc.Rotation)
+		} else {
+			opts = append(opts, WithRotationPeriod(d))
+		}
+	}
+	if fc.ConsoleOnly != nil {
+		opts = append(opts, WithConsoleOnly(*fc.ConsoleOnly))
+	}
+	if s := fc.Sinks; s != nil {
+		if s.SplitLevels != nil {
+			opts = append(opts, WithSplitLevels(*s.SplitLevels))
+		}
+		if s.PerLoggerErrorFile != nil {
+			opts = append(opts, WithPerLoggerErrorFile(*s.PerLoggerErrorFile))
+		}
+		if s.SharedFiles != nil {
+			shared := make(map[string]bool, len(s.SharedFiles))
+			for name, enable := range s.SharedFiles {
+				if err := ValidateLoggerName(name, 0); err != nil {
+					problem("sinks.shared_files: %v", err)
+					continue
+				}
+				shared[name] = enable
+			}
+			opts = append(opts, func(cfg *Config) { cfg.SharedFiles = shared })
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, &ConfigFileError{Path: path, Problems: problems}
+	}
+	return opts, nil
+}
+
+// parseLevel 解析 debug、info、warn、error、dpanic、panic、fatal。
+func parseLevel(text string) (zapcore.Level, error) {
+	var level zapcore.Level
+	err := level.UnmarshalText([]byte(strings.ToLower(strings.TrimSpace(text))))
+	return level, err
+}
+
+// parseConfigDuration 在 time.ParseDuration 的基础上支持以天为单位，如 10d。
+func parseConfigDuration(text string) (time.Duration, error) {
+	text = strings.TrimSpace(text)
+	if strings.HasSuffix(text, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(text, "d"))
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(text)
+}
+
+// sortedKeys 返回排序后的 map 键，保证错误信息顺序稳定。
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ApplyConfigFile 读取配置文件并通过 SetLog 一次性应用；文件有任何问题时返回错误且不修改当前配置。
+func (m *Manager) ApplyConfigFile(path string) error {
+	fc, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+	return m.applyFileConfig(path, fc, nil, nil)
+}
+
+// applyFileConfig 在当前配置上应用已校验的配置。prev 为上一次应用的文件内容（监听时），
+// 其中设置过而 fc 中已删除的字段恢复为 base 中的值，其余字段保留当前配置。
+func (m *Manager) applyFileConfig(path string, fc *FileConfig, prev *FileConfig, base *Config) error {
+	opts, err := fc.options(path)
+	if err != nil {
+		return err
+	}
+
+	current := m.getConfig()
+	env := current.Env
+	if fc.Env != "" {
+		env = Env(fc.Env)
+	} else if prev != nil && prev.Env != "" {
+		env = base.Env
+	}
+	if fc.Env == "" && fc.Level == "" {
+		// 文件既没有 env 也没有 level 时保留当前显式设置的等级；等级来自上一次的文件时恢复为 base 的等级
+		keep := current
+		if prev != nil && (prev.Env != "" || prev.Level != "") {
+			keep = *base
+		}
+		if keep.levelOverride {
+			opts = append([]LogOption{WithLevel(keep.Level)}, opts...)
+		}
+	}
+	if prev != nil {
+		opts = append([]LogOption{restoreRemovedKeys(prev, fc, *base)}, opts...)
+	}
+	if err := m.setLogFrom(env, fc.Env != "", opts...); err != nil {
+		return err
+	}
+	m.cfgMu.Lock()
+	m.configPath = path
+	m.cfgMu.Unlock()
+	return nil
+}
+
+// restoreRemovedKeys 返回把 prev 中设置过、fc 中已删除的字段恢复为 base 值的选项。
+func restoreRemovedKeys(prev, fc *FileConfig, base Config) LogOption {
+	base = cloneConfig(base)
+	return func(cfg *Config) {
+		if prev.Levels != nil && fc.Levels == nil {
+			cfg.LoggerLevels = base.LoggerLevels
+		}
+		if prev.LogDir != "" && fc.LogDir == "" {
+			cfg.LogDir = base.LogDir
+		}
+		if prev.MaxAge != "" && fc.MaxAge == "" {
+			cfg.WithMaxAge = base.WithMaxAge
+		}
+		if prev.MaxFiles != nil && fc.MaxFiles == nil {
+			cfg.MaxFiles = base.MaxFiles
+		}
+		if prev.Rotation != "" && fc.Rotation == "" {
+			cfg.RotationPeriod = base.RotationPeriod
+			cfg.WithRotationTime = base.WithRotationTime
+		}
+		if prev.ConsoleOnly != nil && fc.ConsoleOnly == nil {
+			cfg.ConsoleOnly = base.ConsoleOnly
+		}
+		if prev.Sinks == nil {
+			return
+		}
+		sinks := fc.Sinks
+		if sinks == nil {
+			sinks = &SinksConfig{}
+		}
+		if prev.Sinks.SplitLevels != nil && sinks.SplitLevels == nil {
+			cfg.SplitLevels = base.SplitLevels
+		}
+		if prev.Sinks.PerLoggerErrorFile != nil && sinks.PerLoggerErrorFile == nil {
+			cfg.PerLoggerErrorFile = base.PerLoggerErrorFile
+		}
+		if prev.Sinks.SharedFiles != nil && sinks.SharedFiles == nil {
+			cfg.SharedFiles = base.SharedFiles
+		}
+	}
+}
+
+// WatchConfigFile 应用配置文件并监听其变化，变化后重新校验并在当前配置上应用，
+// 监听期间通过 SetLog、SetLevel 等方法做的修改不会被重新加载覆盖（文件中出现的字段除外）。
+// 从文件中删除的字段恢复为开始监听时的值。
+// 校验失败时保留当前配置并调用 onError（为空时打印到 stderr）。返回的函数用于停止监听。
+func (m *Manager) WatchConfigFile(path string, onError func(error)) (stop func(), err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fc, err := parseConfigFile(path, data)
+	if err != nil {
+		return nil, err
+	}
+	base := cloneConfig(m.getConfig())
+	if err := m.applyFileConfig(path, fc, nil, nil); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	// 监听所在目录，兼容编辑器替换文件与 Kubernetes ConfigMap 的符号链接切换
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go m.watchConfig(watcher, path, &base, fc, data, onError, done)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			watcher.Close()
+		})
+	}, nil
+}
+
+// watchConfig 合并短时间内的多次变化，内容变化时重新应用配置；prev 为最近一次成功应用的文件内容。
+func (m *Manager) watchConfig(watcher *fsnotify.Watcher, path string, base *Config, prev *FileConfig, last []byte, onError func(error), done chan struct{}) {
+	report := func(err error) {
+		if onError != nil {
+			onError(err)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "%v, keeping the current config\n", err)
+	}
+
+	timer := time.NewTimer(configReloadDelay)
+	timer.Stop()
+	defer timer.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case _, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			timer.Reset(configReloadDelay)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			report(err)
+		case <-timer.C:
+			data, err := os.ReadFile(path)
+			if err != nil {
+				report(err)
+				continue
+			}
+			if bytes.Equal(data, last) {
+				continue
+			}
+			fc, err := parseConfigFile(path, data)
+			if err == nil {
+				err = m.applyFileConfig(path, fc, prev, base)
+			}
+			if err != nil {
+				report(err)
+				continue
+			}
+			prev, last = fc, data
+		}
+	}
+}
+
+// ApplyConfigFile 读取配置文件并应用到全局实例。
+func ApplyConfigFile(path string) error {
+	return getDefaultManager().ApplyConfigFile(path)
+}
+
+// WatchConfigFile 将配置文件应用到全局实例并监听变化。
+func WatchConfigFile(path string, onError func(error)) (stop func(), err error) {
+	return getDefaultManager().WatchConfigFile(path, onError)
+}
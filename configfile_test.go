@@ -0,0 +1,205 @@
+package zlog
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// TestApplyConfigFileYAML 测试 YAML 配置文件一次性应用
+func TestApplyConfigFileYAML(t *testing.T) {
+	mgr, tmpDir := newTestManager(t)
+
+	logsDir := filepath.Join(tmpDir, "logs")
+	path := filepath.Join(tmpDir, "zlog.yaml")
+	writeFile(t, path, `
+env: warn
+levels:
+  order.payment: debug
+log_dir: `+logsDir+`
+max_age: 3d
+max_files: 5
+rotation: 1h
+sinks:
+  per_logger_error_file: true
+  shared_files:
+    order: true
+`)
+
+	if err := mgr.ApplyConfigFile(path); err != nil {
+		t.Fatalf("ApplyConfigFile: %v", err)
+	}
+	cfg := mgr.getConfig()
+	if cfg.Env != ENV_WARN || cfg.Level != zapcore.WarnLevel || cfg.LogDir != logsDir {
+		t.Fatalf("env/level/dir not applied: %+v", cfg)
+	}
+	if cfg.WithMaxAge != 72 || cfg.MaxFiles != 5 || cfg.RotationPeriod != time.Hour || !cfg.PerLoggerErrorFile {
+		t.Fatalf("retention/sinks not applied: %+v", cfg)
+	}
+	if level, ok := cfg.loggerLevel("order.payment.refund"); !ok || level != zapcore.DebugLevel {
+		t.Fatalf("per-logger level not applied: %v %v", level, ok)
+	}
+	if cfg.fileOwner("order.payment") != "order" {
+		t.Fatal("shared files not applied")
+	}
+}
+
+// TestApplyConfigFileJSON 测试 JSON 配置文件，省略的字段保留当前设置
+func TestApplyConfigFileJSON(t *testing.T) {
+	mgr, tmpDir := newTestManager(t, WithLevel(zapcore.DebugLevel), WithMaxAge(48))
+
+	path := filepath.Join(tmpDir, "zlog.json")
+	writeFile(t, path, `{"console_only": true, "max_files": 3}`)
+
+	if err := mgr.ApplyConfigFile(path); err != nil {
+		t.Fatalf("ApplyConfigFile: %v", err)
+	}
+	cfg := mgr.getConfig()
+	if !cfg.ConsoleOnly || cfg.MaxFiles != 3 {
+		t.Fatalf("file settings not applied: %+v", cfg)
+	}
+	if cfg.Level != zapcore.DebugLevel || cfg.WithMaxAge != 48 || cfg.LogDir != tmpDir {
+		t.Fatalf("omitted settings should be kept: %+v", cfg)
+	}
+}
+
+// TestApplyConfigFileInvalid 测试校验失败时报告全部问题且不应用任何设置
+func TestApplyConfigFileInvalid(t *testing.T) {
+	mgr, tmpDir := newTestManager(t)
+
+	path := filepath.Join(tmpDir, "zlog.yml")
+	writeFile(t, path, `
+env: debug
+level: loud
+levels:
+  ../x: debug
+max_age: 10m
+console_only: true
+`)
+	before := mgr.getConfig()
+	err := mgr.ApplyConfigFile(path)
+	var cfgErr *ConfigFileError
+	if !errors.As(err, &cfgErr) || len(cfgErr.Problems) != 3 {
+		t.Fatalf("expected 3 problems, got %v", err)
+	}
+	after := mgr.getConfig()
+	if after.Env != before.Env || after.ConsoleOnly != before.ConsoleOnly {
+		t.Fatal("invalid config must not be half-applied")
+	}
+
+	writeFile(t, path, "unknown_key: 1\n")
+	if err := mgr.ApplyConfigFile(path); err == nil || !strings.Contains(err.Error(), "unknown_key") {
+		t.Fatalf("unknown fields should be rejected, got %v", err)
+	}
+}
+
+// TestWatchConfigFile 测试监听配置文件变化并在内容无效时保留当前配置
+func TestWatchConfigFile(t *testing.T) {
+	mgr, tmpDir := newTestManager(t)
+
+	path := filepath.Join(tmpDir, "zlog.yaml")
+	writeFile(t, path, "level: info\n")
+
+	errs := make(chan error, 10)
+	stop, err := mgr.WatchConfigFile(path, func(err error) { errs <- err })
+	if err != nil {
+		t.Fatalf("WatchConfigFile: %v", err)
+	}
+	defer stop()
+
+	writeFile(t, path, "level: error\n")
+	waitFor(t, func() bool { return mgr.getConfig().Level == zapcore.ErrorLevel })
+
+	t.Log("写入无效内容时通过回调报告错误，当前配置不变")
+	writeFile(t, path, "level: nope\n")
+	select {
+	case err := <-errs:
+		if !strings.Contains(err.Error(), "nope") {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("expected a validation error from the watcher")
+	}
+	if mgr.getConfig().Level != zapcore.ErrorLevel {
+		t.Fatal("invalid reload changed the level")
+	}
+}
+
+// TestWatchConfigFileRemovedKeys 测试从文件中删除的字段在重新加载后恢复为开始监听时的配置
+func TestWatchConfigFileRemovedKeys(t *testing.T) {
+	mgr, tmpDir := newTestManager(t, WithMaxAge(72))
+
+	path := filepath.Join(tmpDir, "zlog.yaml")
+	writeFile(t, path, "max_age: 2d\nlevels: {order: warn}\nconsole_only: true\n")
+
+	stop, err := mgr.WatchConfigFile(path, nil)
+	if err != nil {
+		t.Fatalf("WatchConfigFile: %v", err)
+	}
+	defer stop()
+	if cfg := mgr.getConfig(); cfg.WithMaxAge != 48 || !cfg.ConsoleOnly || len(cfg.LoggerLevels) != 1 {
+		t.Fatalf("file not applied: %+v", cfg)
+	}
+
+	writeFile(t, path, "level: warn\n")
+	waitFor(t, func() bool { return mgr.getConfig().Level == zapcore.WarnLevel })
+	if cfg := mgr.getConfig(); cfg.WithMaxAge != 72 || cfg.ConsoleOnly || len(cfg.LoggerLevels) != 0 {
+		t.Fatalf("removed keys kept their old values: max_age=%d console_only=%v levels=%v", cfg.WithMaxAge, cfg.ConsoleOnly, cfg.LoggerLevels)
+	}
+}
+
+// TestWatchConfigFileKeepsRuntimeChanges 测试重新加载不会覆盖监听期间通过 SetLevel / SetLog 做的修改
+func TestWatchConfigFileKeepsRuntimeChanges(t *testing.T) {
+	mgr, tmpDir := newTestManager(t)
+
+	path := filepath.Join(tmpDir, "zlog.yaml")
+	writeFile(t, path, "max_files: 3\n")
+
+	stop, err := mgr.WatchConfigFile(path, nil)
+	if err != nil {
+		t.Fatalf("WatchConfigFile: %v", err)
+	}
+	defer stop()
+
+	t.Log("监听期间修改等级与保留时长")
+	if err := mgr.SetLogE(mgr.getConfig().Env, WithMaxAge(96)); err != nil {
+		t.Fatalf("SetLogE: %v", err)
+	}
+	mgr.SetLevel(zapcore.ErrorLevel)
+
+	writeFile(t, path, "max_files: 5\n")
+	waitFor(t, func() bool { return mgr.getConfig().MaxFiles == 5 })
+	if cfg := mgr.getConfig(); cfg.Level != zapcore.ErrorLevel || cfg.WithMaxAge != 96 {
+		t.Fatalf("reload reverted runtime changes: level=%v max_age=%d", cfg.Level, cfg.WithMaxAge)
+	}
+
+	t.Log("文件中出现的字段仍以文件为准")
+	writeFile(t, path, "max_files: 5\nmax_age: 1d\n")
+	waitFor(t, func() bool { return mgr.getConfig().WithMaxAge == 24 })
+	if cfg := mgr.getConfig(); cfg.Level != zapcore.ErrorLevel {
+		t.Fatalf("reload reverted SetLevel: %v", cfg.Level)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before timeout")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
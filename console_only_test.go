@@ -53,7 +53,7 @@ func TestConsoleOnlyWithOptions(t *testing.T) {
 func TestManagerConsoleOnly(t *testing.T) {
 	t.Log("=== 测试 Manager 实例的仅终端模式 ===")
 
-	mgr := NewManager()
+	mgr, _ := newTestManager(t)
 	mgr.SetLog(ENV_DEBUG)
 
 	t.Log("步骤1: Manager 默认模式")
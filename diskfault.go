@@ -0,0 +1,107 @@
+package zlog
+
+import (
+	"errors"
+	"strings"
+	"syscall"
+)
+
+// DiskFault 描述导致日志无法写入的磁盘故障类型。
+type DiskFault int
+
+const (
+	DiskOK       DiskFault = iota // 非磁盘故障
+	DiskFull                      // 磁盘已满（ENOSPC）
+	DiskReadOnly                  // 只读文件系统（EROFS）
+)
+
+// String 返回故障名称。
+func (f DiskFault) String() string {
+	switch f {
+	case DiskFull:
+		return "disk full"
+	case DiskReadOnly:
+		return "read-only filesystem"
+	default:
+		return "ok"
+	}
+}
+
+// DetectDiskFault 判断错误是否由磁盘已满或只读文件系统引起，
+// 同时展开 Unwrap 与 pkg/errors 的 Cause 链；rotatelogs 打开文件失败时只保留错误文本，因此最后按文本匹配。
+func DetectDiskFault(err error) DiskFault {
+	// errors.Is 展开 Unwrap 链，外层循环再展开 Cause 链
+	e := err
+	for depth := 0; e != nil && depth < 16; depth++ {
+		switch {
+		case errors.Is(e, syscall.ENOSPC):
+			return DiskFull
+		case errors.Is(e, syscall.EROFS):
+			return DiskReadOnly
+		}
+		c, ok := e.(interface{ Cause() error })
+		if !ok {
+			break
+		}
+		e = c.Cause()
+	}
+
+	if err == nil {
+		return DiskOK
+	}
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, syscall.ENOSPC.Error()):
+		return DiskFull
+	case strings.Contains(msg, syscall.EROFS.Error()):
+		return DiskReadOnly
+	}
+	return DiskOK
+}
+
+// ringBuffer 在降级期间缓存最近的日志条目，满时丢弃最旧的条目。
+type ringBuffer struct {
+	entries [][]byte
+	start   int
+	size    int
+}
+
+func newRingBuffer(capacity int) *ringBuffer {
+	if capacity <= 0 {
+		capacity = defaultFallbackBufferSize
+	}
+	return &ringBuffer{entries: make([][]byte, capacity)}
+}
+
+// push 追加一条日志副本，缓冲区已满时覆盖最旧的条目并返回 true。
+func (b *ringBuffer) push(p []byte) (dropped bool) {
+	entry := append([]byte(nil), p...)
+	if b.size == len(b.entries) {
+		b.entries[b.start] = entry
+		b.start = (b.start + 1) % len(b.entries)
+		return true
+	}
+	b.entries[(b.start+b.size)%len(b.entries)] = entry
+	b.size++
+	return false
+}
+
+// flush 按写入顺序将缓存写出，失败时保留未写出的条目（部分写出的条目只保留剩余字节）。
+func (b *ringBuffer) flush(write func([]byte) (int, error)) error {
+	for b.size > 0 {
+		entry := b.entries[b.start]
+		if n, err := write(entry); err != nil {
+			b.entries[b.start] = entry[n:]
+			return err
+		}
+		b.entries[b.start] = nil
+		b.start = (b.start + 1) % len(b.entries)
+		b.size--
+	}
+	return nil
+}
+
+// len 返回缓存的条目数。
+func (b *ringBuffer) len() int {
+	return b.size
+}
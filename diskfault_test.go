@@ -0,0 +1,259 @@
+package zlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// causeError 模拟 pkg/errors 的 Cause 包装（不支持 Unwrap）。
+type causeError struct {
+	msg   string
+	cause error
+}
+
+func (e *causeError) Error() string { return e.msg }
+func (e *causeError) Cause() error  { return e.cause }
+
+// TestDetectDiskFault 测试磁盘故障识别
+func TestDetectDiskFault(t *testing.T) {
+	full := &os.PathError{Op: "write", Path: "api_info.log", Err: syscall.ENOSPC}
+	cases := []struct {
+		err  error
+		want DiskFault
+	}{
+		{nil, DiskOK},
+		{errors.New("other"), DiskOK},
+		{full, DiskFull},
+		{fmt.Errorf("wrapped: %w", &os.PathError{Op: "open", Path: "x", Err: syscall.EROFS}), DiskReadOnly},
+		{&causeError{msg: "failed to acquite target io.Writer", cause: full}, DiskFull},
+		{errors.New("failed to open file x%Y.log: open x.log: " + syscall.ENOSPC.Error()), DiskFull},
+	}
+	for _, c := range cases {
+		if got := DetectDiskFault(c.err); got != c.want {
+			t.Fatalf("DetectDiskFault(%v) = %s, want %s", c.err, got, c.want)
+		}
+	}
+}
+
+// diskWriter 在 full 为 true 时返回 ENOSPC，用于注入磁盘已满。
+type diskWriter struct {
+	flakyWriter
+}
+
+func (w *diskWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.fail {
+		return 0, &os.PathError{Op: "write", Path: "api_info.log", Err: syscall.ENOSPC}
+	}
+	return w.buf.Write(p)
+}
+
+// TestResilientWriterDiskFull 测试磁盘已满时进入环形缓冲降级，空间恢复后写回
+func TestResilientWriterDiskFull(t *testing.T) {
+	target := &diskWriter{}
+	target.fail = true
+
+	var (
+		mu     sync.Mutex
+		faults []DiskFault
+	)
+	policy := writePolicy{
+		handler:    func(WriteError) {},
+		fallback:   FallbackBuffer,
+		bufferSize: 2,
+		minBackoff: 20 * time.Millisecond,
+		maxBackoff: 20 * time.Millisecond,
+		onDiskFault: func(f DiskFault, _ error) {
+			mu.Lock()
+			faults = append(faults, f)
+			mu.Unlock()
+		},
+	}
+	w := newResilientWriter("api_info.log", func() (zapcore.WriteSyncer, error) { return target, nil }, policy)
+	var drops int
+	w.setHooks(nil, nil, func() { drops++ })
+
+	for _, line := range []string{"a\n", "b\n", "c\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatalf("buffer fallback should not return errors: %v", err)
+		}
+	}
+	h := w.health()
+	if h.Healthy || h.Fault != DiskFull || h.Buffered != 2 || h.Dropped != 1 || drops != 1 {
+		t.Fatalf("unexpected degraded health: %+v drops=%d", h, drops)
+	}
+	mu.Lock()
+	if len(faults) == 0 || faults[0] != DiskFull {
+		t.Fatalf("expected disk fault callback, got %v", faults)
+	}
+	mu.Unlock()
+
+	t.Log("空间恢复后先写回缓存")
+	target.mu.Lock()
+	target.fail = false
+	target.mu.Unlock()
+	time.Sleep(25 * time.Millisecond)
+	if _, err := w.Write([]byte("d\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got := target.buf.String(); got != "b\nc\nd\n" {
+		t.Fatalf("unexpected content after resume: %q", got)
+	}
+	if h := w.health(); !h.Healthy || h.Fault != DiskOK || h.Buffered != 0 {
+		t.Fatalf("unexpected health after resume: %+v", h)
+	}
+}
+
+// partialWriter 剩余空间不足时只写入一部分并返回 ENOSPC。
+type partialWriter struct {
+	flakyWriter
+	space int
+}
+
+func (w *partialWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(p) <= w.space {
+		w.space -= len(p)
+		return w.buf.Write(p)
+	}
+	n, _ := w.buf.Write(p[:w.space])
+	w.space -= n
+	return n, &os.PathError{Op: "write", Path: "api_info.log", Err: syscall.ENOSPC}
+}
+
+func (w *partialWriter) grow(n int) {
+	w.mu.Lock()
+	w.space += n
+	w.mu.Unlock()
+}
+
+// TestResilientWriterBufferFlushOnSync 测试部分写入只缓存剩余字节，恢复后 Sync 与 close 会写回缓存
+func TestResilientWriterBufferFlushOnSync(t *testing.T) {
+	target := &partialWriter{space: 3}
+	policy := writePolicy{
+		handler:    func(WriteError) {},
+		fallback:   FallbackBuffer,
+		minBackoff: 20 * time.Millisecond,
+		maxBackoff: 20 * time.Millisecond,
+	}
+	w := newResilientWriter("api_info.log", func() (zapcore.WriteSyncer, error) { return target, nil }, policy)
+
+	if _, err := w.Write([]byte("hello\n")); err != nil {
+		t.Fatalf("buffer fallback should not return errors: %v", err)
+	}
+	if h := w.health(); h.Healthy || h.Buffered != 1 {
+		t.Fatalf("unexpected degraded health: %+v", h)
+	}
+
+	t.Log("空间恢复后没有新日志，Sync 也会写回缓存且不重复已写入的字节")
+	target.grow(100)
+	time.Sleep(25 * time.Millisecond)
+	if err := w.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if got := target.buf.String(); got != "hello\n" {
+		t.Fatalf("unexpected content after sync: %q", got)
+	}
+	if h := w.health(); !h.Healthy || h.Buffered != 0 {
+		t.Fatalf("unexpected health after sync: %+v", h)
+	}
+
+	t.Log("关闭时即使仍在退避期也会尝试写回缓存")
+	target.mu.Lock()
+	target.space = 0
+	target.mu.Unlock()
+	if _, err := w.Write([]byte("bye\n")); err != nil {
+		t.Fatal(err)
+	}
+	target.grow(100)
+	if err := w.close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := target.buf.String(); got != "hello\nbye\n" {
+		t.Fatalf("unexpected content after close: %q", got)
+	}
+}
+
+// TestEmergencyCleanup 测试紧急清理从最旧的文件开始删除，并保留正在写入的文件和外部文件
+func TestEmergencyCleanup(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := registerLogFile(filepath.Join(tmpDir, "api_info.log")); err != nil {
+		t.Fatal(err)
+	}
+	today := time.Now().In(location)
+	name := func(offset int) string {
+		return "api_info" + today.AddDate(0, 0, -offset).Format("2006-01-02") + ".log"
+	}
+	files := []string{name(0), name(1), name(2), name(3), "backup2020-01-01.log"}
+	for _, f := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, f), []byte(strings.Repeat("x", 100)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := runEmergencyCleanup(cleanupOptions{dir: tmpDir}, 150)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Emergency || len(report.Deleted) != 2 || report.BytesReclaimed != 200 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	for _, f := range []string{name(0), name(1), "backup2020-01-01.log"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, f)); err != nil {
+			t.Fatalf("%s must be kept: %v", f, err)
+		}
+	}
+
+	t.Log("要求释放的空间超过可删除文件时，仍保留最新文件；归档失败也照常删除并记录失败")
+	full := ArchiverFunc(func(context.Context, string) error { return syscall.ENOSPC })
+	report, _ = runEmergencyCleanup(cleanupOptions{dir: tmpDir, archiver: full}, 1<<30)
+	if len(report.Deleted) != 1 || filepath.Base(report.Deleted[0].Path) != name(1) {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if len(report.Failures) != 1 || len(report.Archived) != 0 || !errors.Is(report.Failures[0].Err, syscall.ENOSPC) {
+		t.Fatalf("expected the archive failure to be reported: %+v", report)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, name(1))); !os.IsNotExist(err) {
+		t.Fatalf("emergency cleanup must delete even when archiving fails: %v", err)
+	}
+}
+
+// TestManagerDiskFaultCleanup 测试磁盘已满时触发紧急清理
+func TestManagerDiskFaultCleanup(t *testing.T) {
+	reports := make(chan CleanupReport, 1)
+	mgr, tmpDir := newTestManager(t,
+		WithEmergencyCleanup(1),
+		WithCleanupCallback(func(r CleanupReport) { reports <- r }),
+	)
+	mgr.Logger("api").Info("登记前缀")
+	old := filepath.Join(tmpDir, "api_info2020-01-01.log")
+	if err := os.WriteFile(old, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mgr.handleDiskFault(DiskReadOnly, syscall.EROFS)
+	mgr.handleDiskFault(DiskFull, syscall.ENOSPC)
+	select {
+	case r := <-reports:
+		if !r.Emergency || len(r.Deleted) != 1 || r.Deleted[0].Path != old {
+			t.Fatalf("unexpected emergency report: %+v", r)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected emergency cleanup")
+	}
+	if runs := mgr.Stats().Cleanup.EmergencyRuns; runs != 1 {
+		t.Fatalf("EmergencyRuns = %d", runs)
+	}
+}
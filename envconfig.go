@@ -0,0 +1,288 @@
+package zlog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// 支持的环境变量。优先级：LogOption 与 Set* 方法 > 环境变量 > 内置默认值。
+const (
+	envVarEnv         = "ZLOG_ENV"          // 运行环境，如 debug、info、pro
+	envVarLevel       = "ZLOG_LEVEL"        // 全局等级与点分名称等级，如 "info,api=debug,db=warn"
+	envVarMaxAge      = "ZLOG_MAX_AGE"      // 保留时长，整数为小时，也可写 240h、10d
+	envVarRotation    = "ZLOG_ROTATION"     // 切割周期，整数为小时，也可写 1h、30m、1d
+	envVarConsoleOnly = "ZLOG_CONSOLE_ONLY" // 仅输出到终端，true/false/1/0
+	envVarFormat      = "ZLOG_FORMAT"       // 时间格式：sec、msec 或 Go 时间模板
+	envVarTZ          = "ZLOG_TZ"           // 时区，如 Asia/Shanghai、UTC
+	envVarDir         = "ZLOG_DIR"          // 日志目录
+	envVarLegacyDir   = "BITLOGIN_LOG_DIR"  // 日志目录（旧名称，ZLOG_DIR 优先）
+)
+
+// envLookup 与 os.LookupEnv 相同，便于测试替换。
+type envLookup func(string) (string, bool)
+
+// envValue 返回去掉首尾空白后的非空环境变量。
+func envValue(lookup envLookup, key string) (string, bool) {
+	v, ok := lookup(key)
+	v = strings.TrimSpace(v)
+	return v, ok && v != ""
+}
+
+// envLogDir 返回环境变量指定的日志目录。
+func envLogDir(lookup envLookup) (string, bool) {
+	if dir, ok := envValue(lookup, envVarDir); ok {
+		return dir, true
+	}
+	return envValue(lookup, envVarLegacyDir)
+}
+
+// DefaultErrorName 返回未指定 WithErrorName 时共享错误文件的前缀：默认 log_error，
+// 设置 ZLOG_FILE_PREFIX 时为 <prefix>_error。
+func DefaultErrorName() string {
+	cfg := builtinConfig()
+	_ = applyEnv(&cfg, os.LookupEnv)
+	return cfg.ErrorLoggerName
+}
+
+// DefaultDate 返回未指定 WithDate 时的日志时间格式：默认 DATE_SEC，设置 ZLOG_FORMAT 时为其指定的格式。
+func DefaultDate() EnvDate {
+	cfg := builtinConfig()
+	_ = applyEnv(&cfg, os.LookupEnv)
+	return cfg.formDate
+}
+
+// applyEnv 将环境变量应用到默认配置，返回无法解析而被忽略的变量。
+func applyEnv(cfg *Config, lookup envLookup) []error {
+	var errs []error
+	invalid := func(key, value string, err error) {
+		errs = append(errs, fmt.Errorf("zlog: invalid %s=%q: %v", key, value, err))
+	}
+
+	if prefix, ok := envValue(lookup, defaultPrefixEnv); ok {
+		cfg.DefaultLoggerName = prefix
+		cfg.ErrorLoggerName = prefix + "_error"
+	}
+	if dir, ok := envLogDir(lookup); ok {
+		cfg.LogDir = dir
+	}
+	if v, ok := envValue(lookup, envVarEnv); ok {
+		if knownEnv(Env(v)) {
+			cfg.Env = Env(v)
+		} else {
+			invalid(envVarEnv, v, fmt.Errorf("unknown env"))
+		}
+	}
+	if v, ok := envValue(lookup, envVarLevel); ok {
+		if err := applyEnvLevels(cfg, v); err != nil {
+			invalid(envVarLevel, v, err)
+		}
+	}
+	cfg.Level = cfg.baseLevel()
+	if v, ok := envValue(lookup, envVarMaxAge); ok {
+		if d, err := parseEnvHours(v); err != nil || d < time.Hour {
+			invalid(envVarMaxAge, v, fmt.Errorf("want hours or a duration of at least 1h"))
+		} else {
+			cfg.WithMaxAge = int(d / time.Hour)
+		}
+	}
+	if v, ok := envValue(lookup, envVarRotation); ok {
+		if d, err := parseEnvHours(v); err != nil || d < time.Minute {
+			invalid(envVarRotation, v, fmt.Errorf("want hours or a duration of at least 1m"))
+		} else {
+			cfg.RotationPeriod = d
+		}
+	}
+	if v, ok := envValue(lookup, envVarConsoleOnly); ok {
+		if b, err := strconv.ParseBool(v); err != nil {
+			invalid(envVarConsoleOnly, v, err)
+		} else {
+			cfg.ConsoleOnly = b
+		}
+	}
+	if v, ok := envValue(lookup, envVarFormat); ok {
+		if date, err := parseEnvFormat(v); err != nil {
+			invalid(envVarFormat, v, err)
+		} else {
+			cfg.formDate = date
+		}
+	}
+	return errs
+}
+
+// applyEnvLevels 解析 "info,api=debug,db=warn"：不带名称的一项为全局等级，其余为点分名称的等级覆盖。
+func applyEnvLevels(cfg *Config, spec string) error {
+	var global *zapcore.Level
+	levels := make(map[string]zapcore.Level)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, text := "", item
+		if i := strings.IndexByte(item, '='); i >= 0 {
+			name, text = strings.TrimSpace(item[:i]), item[i+1:]
+			if err := ValidateLoggerName(name, 0); err != nil {
+				return err
+			}
+		}
+		level, err := parseLevel(text)
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			if global != nil {
+				return fmt.Errorf("more than one global level")
+			}
+			global = &level
+			continue
+		}
+		levels[name] = level
+	}
+
+	if global != nil {
+		cfg.envLevel, cfg.envLevelSet = *global, true
+	}
+	if len(levels) > 0 {
+		cfg.LoggerLevels = levels
+	}
+	return nil
+}
+
+// parseEnvHours 解析整数小时或 parseConfigDuration 支持的时长。
+func parseEnvHours(v string) (time.Duration, error) {
+	if hours, err := strconv.Atoi(v); err == nil {
+		return time.Duration(hours) * time.Hour, nil
+	}
+	return parseConfigDuration(v)
+}
+
+// parseEnvFormat 解析时间格式。
+func parseEnvFormat(v string) (EnvDate, error) {
+	switch strings.ToLower(v) {
+	case "sec":
+		return DATE_SEC, nil
+	case "msec":
+		return DATE_MSEC, nil
+	}
+	if strings.Contains(v, "2006") {
+		return EnvDate(v), nil
+	}
+	return "", fmt.Errorf("want sec, msec or a Go time layout")
+}
+
+// envLocation 返回 ZLOG_TZ 指定的时区。
+func envLocation(lookup envLookup) (*time.Location, bool, error) {
+	tz, ok := envValue(lookup, envVarTZ)
+	if !ok {
+		return nil, false, nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, false, fmt.Errorf("zlog: invalid %s=%q: %v", envVarTZ, tz, err)
+	}
+	return loc, true, nil
+}
+
+// ConfigSetting 是生效配置中的一项及其来源。
+type ConfigSetting struct {
+	Key    string
+	Value  string
+	Source string // "default"、"option" 或提供该值的环境变量名
+}
+
+// configField 描述一项可打印的配置及可能提供它的环境变量。
+type configField struct {
+	key   string
+	vars  []string
+	value func(Config) string
+}
+
+var configFields = []configField{
+	{"env", []string{envVarEnv}, func(c Config) string { return string(c.Env) }},
+	{"level", []string{envVarLevel, envVarEnv}, func(c Config) string { return c.Level.String() }},
+	{"logger_levels", []string{envVarLevel}, func(c Config) string { return formatLevels(c.LoggerLevels) }},
+	{"log_dir", []string{envVarDir, envVarLegacyDir}, func(c Config) string { return c.LogDir }},
+	{"file_prefix", []string{defaultPrefixEnv}, func(c Config) string { return c.DefaultLoggerName }},
+	{"error_prefix", []string{defaultPrefixEnv}, func(c Config) string { return c.ErrorLoggerName }},
+	{"max_age", []string{envVarMaxAge}, func(c Config) string { return (time.Duration(c.WithMaxAge) * time.Hour).String() }},
+	{"rotation", []string{envVarRotation}, func(c Config) string { return c.rotationPeriod().String() }},
+	{"console_only", []string{envVarConsoleOnly}, func(c Config) string { return strconv.FormatBool(c.ConsoleOnly) }},
+	{"format", []string{envVarFormat}, func(c Config) string { return string(c.formDate) }},
+	{"sampling", nil, func(c Config) string { return c.Sampling.String() }},
+	{"max_files", nil, func(c Config) string { return strconv.Itoa(c.MaxFiles) }},
+	{"auto_cleanup", nil, func(c Config) string { return strconv.FormatBool(c.AutoCleanup) }},
+}
+
+// formatLevels 按名称排序输出 name=level 列表。
+func formatLevels(levels map[string]zapcore.Level) string {
+	names := make([]string, 0, len(levels))
+	for name := range levels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + "=" + levels[name].String()
+	}
+	return strings.Join(parts, ",")
+}
+
+// EffectiveConfig 返回当前生效的配置及每一项的来源：与环境变量给出的值不同时为 "option"，
+// 与内置默认值不同时为对应的环境变量名，否则为 "default"。
+func (m *Manager) EffectiveConfig() []ConfigSetting {
+	current := m.getConfig()
+	builtin := builtinConfig()
+	fromEnv := builtinConfig()
+	_ = applyEnv(&fromEnv, os.LookupEnv)
+
+	settings := make([]ConfigSetting, 0, len(configFields)+1)
+	for _, f := range configFields {
+		value := f.value(current)
+		source := "default"
+		switch {
+		case value != f.value(fromEnv):
+			source = "option"
+		case value != f.value(builtin):
+			source = firstSetEnv(f.vars)
+		}
+		settings = append(settings, ConfigSetting{Key: f.key, Value: value, Source: source})
+	}
+
+	tz := ConfigSetting{Key: "tz", Value: location.String(), Source: "default"}
+	if _, ok, _ := envLocation(os.LookupEnv); ok {
+		tz.Source = envVarTZ
+	}
+	return append(settings, tz)
+}
+
+// firstSetEnv 返回第一个设置了的环境变量名。
+func firstSetEnv(vars []string) string {
+	for _, v := range vars {
+		if _, ok := envValue(os.LookupEnv, v); ok {
+			return v
+		}
+	}
+	return "default"
+}
+
+// PrintConfig 按 "key = value (source)" 每行一项输出生效的配置。
+func (m *Manager) PrintConfig(w io.Writer) error {
+	for _, s := range m.EffectiveConfig() {
+		if _, err := fmt.Fprintf(w, "%-13s = %s (%s)\n", s.Key, s.Value, s.Source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PrintConfig 输出全局实例生效的配置。
+func PrintConfig(w io.Writer) error {
+	return getDefaultManager().PrintConfig(w)
+}
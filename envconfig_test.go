@@ -0,0 +1,117 @@
+package zlog
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func fakeEnv(vars map[string]string) envLookup {
+	return func(key string) (string, bool) {
+		v, ok := vars[key]
+		return v, ok
+	}
+}
+
+// TestApplyEnv 测试环境变量覆盖内置默认值
+func TestApplyEnv(t *testing.T) {
+	cfg := builtinConfig()
+	errs := applyEnv(&cfg, fakeEnv(map[string]string{
+		envVarEnv:         "debug",
+		envVarLevel:       "warn, api=debug ,order.payment=error",
+		envVarMaxAge:      "3d",
+		envVarRotation:    "30m",
+		envVarConsoleOnly: "1",
+		envVarFormat:      "msec",
+		defaultPrefixEnv:  "svc",
+		envVarDir:         "/tmp/zlog-env",
+	}))
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if cfg.Env != ENV_DEBUG || cfg.Level != zapcore.WarnLevel {
+		t.Fatalf("ZLOG_LEVEL should win over ZLOG_ENV: env=%s level=%s", cfg.Env, cfg.Level)
+	}
+	if formatLevels(cfg.LoggerLevels) != "api=debug,order.payment=error" {
+		t.Fatalf("unexpected logger levels: %v", cfg.LoggerLevels)
+	}
+	if cfg.WithMaxAge != 72 || cfg.RotationPeriod != 30*time.Minute || !cfg.ConsoleOnly || cfg.formDate != DATE_MSEC {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+	if cfg.DefaultLoggerName != "svc" || cfg.ErrorLoggerName != "svc_error" || cfg.LogDir != "/tmp/zlog-env" {
+		t.Fatalf("unexpected names/dir: %+v", cfg)
+	}
+
+	t.Log("整数的保留时长与切割周期按小时解析")
+	cfg = builtinConfig()
+	_ = applyEnv(&cfg, fakeEnv(map[string]string{envVarMaxAge: "48", envVarRotation: "1"}))
+	if cfg.WithMaxAge != 48 || cfg.RotationPeriod != time.Hour {
+		t.Fatalf("unexpected hours: %d %s", cfg.WithMaxAge, cfg.RotationPeriod)
+	}
+}
+
+// TestApplyEnvInvalid 测试无法解析的变量被忽略并报告
+func TestApplyEnvInvalid(t *testing.T) {
+	cfg := builtinConfig()
+	errs := applyEnv(&cfg, fakeEnv(map[string]string{
+		envVarEnv:         "staging",
+		envVarLevel:       "info,../x=debug",
+		envVarMaxAge:      "10m",
+		envVarConsoleOnly: "maybe",
+		envVarFormat:      "iso",
+	}))
+	if len(errs) != 5 {
+		t.Fatalf("expected 5 errors, got %v", errs)
+	}
+	want := builtinConfig()
+	if cfg.Env != want.Env || cfg.Level != want.Level || cfg.WithMaxAge != want.WithMaxAge || cfg.ConsoleOnly || cfg.LoggerLevels != nil {
+		t.Fatalf("invalid values must not change the config: %+v", cfg)
+	}
+}
+
+// TestEnvPrecedence 测试优先级：LogOption > 环境变量 > 默认值，SetLog 后 ZLOG_LEVEL 仍然生效
+func TestEnvPrecedence(t *testing.T) {
+	t.Setenv(envVarLevel, "warn,api=debug")
+	t.Setenv(envVarMaxAge, "72")
+
+	mgr, tmpDir := newTestManager(t, WithMaxAge(24))
+	cfg := mgr.getConfig()
+	if cfg.Level != zapcore.WarnLevel || cfg.WithMaxAge != 24 {
+		t.Fatalf("expected env level and option max age, got %s/%d", cfg.Level, cfg.WithMaxAge)
+	}
+
+	t.Log("显式指定 env 时按 env 推导等级，优先于 ZLOG_LEVEL 的全局等级")
+	mgr.SetLog(ENV_DEBUG)
+	if cfg := mgr.getConfig(); cfg.Level != zapcore.DebugLevel || cfg.LoggerLevels["api"] != zapcore.DebugLevel {
+		t.Fatalf("explicit env should win over ZLOG_LEVEL, got %s %v", cfg.Level, cfg.LoggerLevels)
+	}
+	if err := mgr.SetLogE(ENV_ERROR); err != nil || mgr.getConfig().Level != zapcore.ErrorLevel {
+		t.Fatalf("SetLogE should apply the env level, got %s (%v)", mgr.getConfig().Level, err)
+	}
+	mgr.SetLog(ENV_DEBUG, WithLevel(zapcore.ErrorLevel), WithLoggerLevel("api", zapcore.InfoLevel))
+	cfg = mgr.getConfig()
+	if cfg.Level != zapcore.ErrorLevel || cfg.LoggerLevels["api"] != zapcore.InfoLevel {
+		t.Fatalf("options should win over env: %s %v", cfg.Level, cfg.LoggerLevels)
+	}
+
+	t.Log("打印生效配置时标出每项来源")
+	sources := map[string]string{}
+	for _, s := range mgr.EffectiveConfig() {
+		sources[s.Key] = s.Source
+	}
+	if sources["level"] != "option" || sources["max_age"] != "option" || sources["log_dir"] != "option" || sources["console_only"] != "default" {
+		t.Fatalf("unexpected sources: %v", sources)
+	}
+	mgr2 := NewManager(WithLogDir(tmpDir), WithAutoCleanup(false))
+	var buf strings.Builder
+	if err := mgr2.PrintConfig(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"level         = warn (ZLOG_LEVEL)", "logger_levels = api=debug (ZLOG_LEVEL)", "max_age       = 72h0m0s (ZLOG_MAX_AGE)", "tz"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("missing %q in:\n%s", want, buf.String())
+		}
+	}
+}
@@ -40,8 +40,18 @@ func setLogDir(dir string) {
 	logsMu.Unlock()
 }
 
-// loadLocation 加载本地时区，失败时兜底为系统默认。
+// Location 返回写日志与切割文件使用的时区，即 ZLOG_TZ 指定的时区，未设置时为本地时区。
+func Location() *time.Location {
+	return location
+}
+
+// loadLocation 加载 ZLOG_TZ 指定的时区，未设置或无效时使用本地时区。
 func loadLocation() *time.Location {
+	if loc, ok, err := envLocation(os.LookupEnv); ok {
+		return loc
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "%v, using local time\n", err)
+	}
 	loc, err := time.LoadLocation("Local")
 	if err != nil {
 		return time.Local
@@ -49,17 +59,16 @@ func loadLocation() *time.Location {
 	return loc
 }
 
-// defaultLogsDir 根据系统选择默认日志目录。
-// 使用程序启动时的工作目录（运行目录）作为根目录。
+// defaultLogsDir 返回默认日志目录：环境变量 ZLOG_DIR / BITLOGIN_LOG_DIR 优先，否则为工作目录下的 logs。
 func defaultLogsDir() string {
-	// 环境变量优先
-	if env := strings.TrimSpace(os.Getenv("ZLOG_DIR")); env != "" {
-		return env
-	}
-	if env := strings.TrimSpace(os.Getenv("BITLOGIN_LOG_DIR")); env != "" {
-		return env
+	if dir, ok := envLogDir(os.LookupEnv); ok {
+		return dir
 	}
+	return workingLogsDir()
+}
 
+// workingLogsDir 使用程序启动时的工作目录（运行目录）作为根目录。
+func workingLogsDir() string {
 	// 获取当前工作目录（程序运行目录）
 	cwd, err := os.Getwd()
 	if err != nil {
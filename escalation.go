@@ -0,0 +1,171 @@
+package zlog
+
+import (
+	"sort"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// LevelEscalation 描述一次 SetLevelFor / SetLoggerLevelFor 设置的临时等级。
+type LevelEscalation struct {
+	Logger  string // 空表示全局等级
+	Level   zapcore.Level
+	Expires time.Time // 到期后恢复配置的等级
+}
+
+// escalation 是一次生效中的临时等级及其到期定时器。
+type escalation struct {
+	LevelEscalation
+	timer *time.Timer
+}
+
+// SetLevelFor 将全局等级临时调整为 level，ttl 到期后自动恢复为配置的等级（期间 SetLevel/SetLog 的修改在恢复后生效）。
+// 返回的函数可提前取消；再次调用会替换当前的临时等级，ttl 不大于 0 时直接取消。
+// 使用 WithLoggerLevel 固定等级的 logger 不受全局临时等级影响，需用 SetLoggerLevelFor 单独调整。
+func (m *Manager) SetLevelFor(level zapcore.Level, ttl time.Duration) (cancel func()) {
+	return m.escalate("", level, ttl)
+}
+
+// SetLoggerLevelFor 将名为 name 的 logger 及其点分后代临时调整为 level，ttl 到期后自动恢复，
+// 优先于 WithLoggerLevel 与全局等级。返回值与 SetLevelFor 相同。
+func (m *Manager) SetLoggerLevelFor(name string, level zapcore.Level, ttl time.Duration) (cancel func()) {
+	return m.escalate(name, level, ttl)
+}
+
+// CancelLevelFor 提前结束 name 的临时等级，空名称表示全局等级。
+func (m *Manager) CancelLevelFor(name string) {
+	m.escMu.Lock()
+	defer m.escMu.Unlock()
+	if e, ok := m.escalations[name]; ok {
+		m.endEscalationLocked(e)
+	}
+}
+
+// LevelEscalations 返回生效中的临时等级，按 logger 名称排序。
+func (m *Manager) LevelEscalations() []LevelEscalation {
+	m.escMu.Lock()
+	defer m.escMu.Unlock()
+	list := make([]LevelEscalation, 0, len(m.escalations))
+	for _, e := range m.escalations {
+		list = append(list, e.LevelEscalation)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Logger < list[j].Logger })
+	return list
+}
+
+// escalate 记录临时等级并启动到期定时器。
+func (m *Manager) escalate(name string, level zapcore.Level, ttl time.Duration) func() {
+	m.escMu.Lock()
+	defer m.escMu.Unlock()
+	if old, ok := m.escalations[name]; ok {
+		m.endEscalationLocked(old)
+	}
+	if ttl <= 0 {
+		return func() {}
+	}
+
+	e := &escalation{LevelEscalation: LevelEscalation{Logger: name, Level: level, Expires: time.Now().Add(ttl)}}
+	if m.escalations == nil {
+		m.escalations = make(map[string]*escalation)
+	}
+	m.escalations[name] = e
+	m.applyEscalationsLocked()
+	e.timer = time.AfterFunc(ttl, func() { m.endEscalation(e) })
+	return func() { m.endEscalation(e) }
+}
+
+// endEscalation 结束 e；e 已被替换或取消时不做任何事。
+func (m *Manager) endEscalation(e *escalation) {
+	m.escMu.Lock()
+	defer m.escMu.Unlock()
+	if m.escalations[e.Logger] == e {
+		m.endEscalationLocked(e)
+	}
+}
+
+// endEscalationLocked 停止定时器并恢复等级。
+func (m *Manager) endEscalationLocked(e *escalation) {
+	if e.timer != nil {
+		e.timer.Stop()
+	}
+	delete(m.escalations, e.Logger)
+	m.applyEscalationsLocked()
+}
+
+// cancelEscalations 结束全部临时等级，在 Close 时调用。
+func (m *Manager) cancelEscalations() {
+	m.escMu.Lock()
+	defer m.escMu.Unlock()
+	for _, e := range m.escalations {
+		m.endEscalationLocked(e)
+	}
+}
+
+// syncLevel 按配置与临时等级设置全局 AtomicLevel。
+func (m *Manager) syncLevel() {
+	m.escMu.Lock()
+	defer m.escMu.Unlock()
+	m.applyEscalationsLocked()
+}
+
+// applyEscalationsLocked 让当前的临时等级生效：全局等级写入 AtomicLevel，按名称的等级交给 registry。
+func (m *Manager) applyEscalationsLocked() {
+	level := m.getConfig().Level
+	var loggers map[string]zapcore.Level
+	for name, e := range m.escalations {
+		if name == "" {
+			level = e.Level
+			continue
+		}
+		if loggers == nil {
+			loggers = make(map[string]zapcore.Level)
+		}
+		loggers[name] = e.Level
+	}
+	m.level.SetLevel(level)
+	m.registry.setEscalations(loggers)
+}
+
+// setEscalations 替换按名称的临时等级，已创建的 core 在下一条日志时生效。
+func (r *loggerRegistry) setEscalations(levels map[string]zapcore.Level) {
+	r.escalated.Store(levels)
+}
+
+// escalations 返回按名称的临时等级，没有时为 nil。
+func (r *loggerRegistry) escalations() map[string]zapcore.Level {
+	levels, _ := r.escalated.Load().(map[string]zapcore.Level)
+	return levels
+}
+
+// escalatedLevel 在名称或其祖先有临时等级时使用临时等级，否则使用 base。
+type escalatedLevel struct {
+	registry *loggerRegistry
+	name     string
+	base     zapcore.LevelEnabler
+}
+
+// Enabled 实现 zapcore.LevelEnabler。
+func (l escalatedLevel) Enabled(lvl zapcore.Level) bool {
+	if levels := l.registry.escalations(); levels != nil {
+		if level, ok := nearestLevel(levels, l.name); ok {
+			return level.Enabled(lvl)
+		}
+	}
+	return l.base.Enabled(lvl)
+}
+
+// SetLevelFor 临时调整全局等级，ttl 到期后自动恢复。
+func SetLevelFor(level zapcore.Level, ttl time.Duration) (cancel func()) {
+	return getDefaultManager().SetLevelFor(level, ttl)
+}
+
+// SetLoggerLevelFor 临时调整指定 logger 的等级，ttl 到期后自动恢复。
+func SetLoggerLevelFor(name string, level zapcore.Level, ttl time.Duration) (cancel func()) {
+	return getDefaultManager().SetLoggerLevelFor(name, level, ttl)
+}
+
+// CancelLevelFor 提前结束临时等级，空名称表示全局等级。
+func CancelLevelFor(name string) {
+	getDefaultManager().CancelLevelFor(name)
+}
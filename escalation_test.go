@@ -0,0 +1,84 @@
+package zlog
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// TestSetLevelFor 测试全局临时等级在到期后恢复为配置的等级
+func TestSetLevelFor(t *testing.T) {
+	mgr, tmpDir := newTestManager(t, WithLevel(zapcore.InfoLevel))
+	logger := mgr.Logger("api")
+	mgr.SetLevelFor(zapcore.DebugLevel, 200*time.Millisecond)
+	logger.Debug("escalated")
+
+	stats := mgr.Stats()
+	if len(stats.Escalations) != 1 || stats.Escalations[0].Logger != "" || stats.Escalations[0].Level != zapcore.DebugLevel {
+		t.Fatalf("escalation not visible in stats: %+v", stats.Escalations)
+	}
+	var buf strings.Builder
+	_ = stats.WritePrometheus(&buf)
+	if !strings.Contains(buf.String(), `zlog_level_escalation_expiry_seconds{logger="",level="debug"}`) {
+		t.Fatalf("missing escalation metric:\n%s", buf.String())
+	}
+
+	t.Log("期间 SetLevel 修改的等级在到期后生效")
+	mgr.SetLevel(zapcore.WarnLevel)
+	if level := mgr.level.Level(); level != zapcore.DebugLevel {
+		t.Fatalf("SetLevel should not end the escalation, got %s", level)
+	}
+	waitFor(t, func() bool { return len(mgr.LevelEscalations()) == 0 })
+	if level := mgr.level.Level(); level != zapcore.WarnLevel {
+		t.Fatalf("expected warn after expiry, got %s", level)
+	}
+	logger.Debug("after expiry")
+	logger.Info("info after expiry")
+	_ = mgr.Sync("api")
+
+	content := readTodayLog(t, tmpDir, "api_info")
+	if !strings.Contains(content, "escalated") || strings.Contains(content, "after expiry") {
+		t.Fatalf("unexpected content: %q", content)
+	}
+}
+
+// TestSetLoggerLevelFor 测试按名称的临时等级作用于后代 logger，并可提前取消
+func TestSetLoggerLevelFor(t *testing.T) {
+	mgr, tmpDir := newTestManager(t, WithLoggerLevel("api.v1", zapcore.ErrorLevel))
+	child := mgr.Logger("api.v1")
+	cancel := mgr.SetLoggerLevelFor("api", zapcore.DebugLevel, time.Hour)
+	child.Debug("child escalated")
+	mgr.Logger("db").Debug("db not escalated")
+	mgr.Logger("db").Info("db info")
+
+	t.Log("提前取消后恢复 WithLoggerLevel 的等级")
+	cancel()
+	child.Warn("child after cancel")
+	_ = mgr.Sync("api.v1")
+	_ = mgr.Sync("db")
+
+	if n := len(mgr.LevelEscalations()); n != 0 {
+		t.Fatalf("expected no escalations after cancel, got %d", n)
+	}
+	content := readTodayLog(t, tmpDir, "api.v1_info")
+	if !strings.Contains(content, "child escalated") || strings.Contains(content, "child after cancel") {
+		t.Fatalf("unexpected child content: %q", content)
+	}
+	if content := readTodayLog(t, tmpDir, "db_info"); strings.Contains(content, "db not escalated") {
+		t.Fatalf("escalation leaked to an unrelated logger: %q", content)
+	}
+
+	t.Log("被替换的临时等级的取消函数不影响新的临时等级")
+	stale := mgr.SetLoggerLevelFor("api", zapcore.DebugLevel, time.Hour)
+	mgr.SetLoggerLevelFor("api", zapcore.InfoLevel, time.Hour)
+	stale()
+	if list := mgr.LevelEscalations(); len(list) != 1 || list[0].Level != zapcore.InfoLevel {
+		t.Fatalf("stale cancel removed the newer escalation: %+v", list)
+	}
+	mgr.CancelLevelFor("api")
+	if n := len(mgr.LevelEscalations()); n != 0 {
+		t.Fatalf("CancelLevelFor left %d escalations", n)
+	}
+}
@@ -0,0 +1,253 @@
+package zlog
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// minIdleSweepInterval 是空闲淘汰检查的最小间隔。
+const minIdleSweepInterval = 10 * time.Millisecond
+
+// dormantCore 是被淘汰 logger 的占位 core：不持有文件，首次写日志时重新打开文件并换回正常 core。
+type dormantCore struct {
+	registry *loggerRegistry
+	owner    *swapCore // 被淘汰的替换点，唤醒时重新登记
+	name     string
+	level    zapcore.LevelEnabler // logger 生效的等级
+	fields   []zapcore.Field
+}
+
+// Enabled 按 logger 生效的等级判断；error 及以上始终写入共享错误文件，因此总是启用。
+func (c *dormantCore) Enabled(lvl zapcore.Level) bool {
+	return lvl >= zapcore.ErrorLevel || c.level.Enabled(lvl)
+}
+
+// With 记录 fields，重建后再附加到新 core。
+func (c *dormantCore) With(fields []zapcore.Field) zapcore.Core {
+	merged := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	merged = append(merged, c.fields...)
+	merged = append(merged, fields...)
+	return &dormantCore{registry: c.registry, owner: c.owner, name: c.name, level: c.level, fields: merged}
+}
+
+// Check 重建 logger 后交给新 core 判断。
+func (c *dormantCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return c.revive().Check(ent, ce)
+}
+
+// Write 重建 logger 后写入新 core。
+func (c *dormantCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.revive().Write(ent, fields)
+}
+
+// Sync 休眠状态没有需要刷新的文件。
+func (c *dormantCore) Sync() error {
+	return nil
+}
+
+// revive 唤醒 logger 并返回附加了 fields 的新 core。
+func (c *dormantCore) revive() zapcore.Core {
+	core := c.registry.revive(c.owner, c.name)
+	if len(c.fields) > 0 {
+		core = core.With(c.fields)
+	}
+	return core
+}
+
+// revive 为已淘汰的 logger 按当前配置重新打开文件并重新登记，必要时淘汰其他最久未使用的 logger。
+// 淘汰后已经通过 Logger 重新创建了同名 logger 时，owner 改为转发到新的 core，同一名称只登记一份。
+func (r *loggerRegistry) revive(owner *swapCore, name string) zapcore.Core {
+	r.mu.Lock()
+	var evicted []*resilientWriter
+	core, ok := r.cores[name]
+	if ok && core != owner {
+		if _, dormant := owner.inner().(*dormantCore); dormant {
+			owner.swap(core)
+		}
+		r.mu.Unlock()
+		return owner.inner()
+	}
+	inner := owner.inner()
+	if _, dormant := inner.(*dormantCore); dormant {
+		cfg := r.cfgFn()
+		inner = r.buildCore(cfg, name)
+		owner.swap(inner)
+		owner.touch(time.Now())
+		r.cores[name] = owner
+		evicted = r.enforceMaxLoggersLocked(cfg, name)
+	}
+	r.mu.Unlock()
+
+	retireWriters(evicted)
+	r.reportPending()
+	return inner
+}
+
+// evictLocked 将 logger 换成休眠 core 并从注册表中移除，返回需要在锁外用 retireWriters 关闭的文件 writer，
+// 调用方需持有 r.mu。调用方保存的 logger 仍持有休眠 core，下次写日志时重新登记。
+func (r *loggerRegistry) evictLocked(name string) []*resilientWriter {
+	cfg := r.cfgFn()
+	core := r.cores[name]
+	core.swap(&dormantCore{registry: r, owner: core, name: name, level: r.levelFor(cfg, name)})
+	delete(r.cores, name)
+	for key := range r.loggers {
+		if key.name == name {
+			delete(r.loggers, key)
+		}
+	}
+
+	// 与其他 logger 共享的文件保持打开
+	closing := r.releaseFileWriters(r.owned[name])
+	delete(r.owned, name)
+
+	owner := cfg.fileOwner(name)
+	if len(closing) > 0 {
+		r.metrics.logger(owner).setWriters(nil)
+	}
+	// 文件仍被后代共享时保留其指标，后代的切割与文件大小计入其中
+	r.metrics.evicted(name, name != owner || len(closing) > 0)
+	return closing
+}
+
+// activeLocked 返回持有文件的 logger 名称，按最近使用时间从旧到新排序。
+func (r *loggerRegistry) activeLocked() []string {
+	names := make([]string, 0, len(r.cores))
+	for name, core := range r.cores {
+		if _, dormant := core.inner().(*dormantCore); !dormant {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return r.cores[names[i]].root.lastUse.Load() < r.cores[names[j]].root.lastUse.Load()
+	})
+	return names
+}
+
+// enforceMaxLoggersLocked 在活跃 logger 超过 MaxLoggers 时淘汰最久未使用的，keep 不参与淘汰。
+func (r *loggerRegistry) enforceMaxLoggersLocked(cfg Config, keep string) []*resilientWriter {
+	if cfg.MaxLoggers <= 0 {
+		return nil
+	}
+	active := r.activeLocked()
+	excess := len(active) - cfg.MaxLoggers
+	var closing []*resilientWriter
+	for _, name := range active {
+		if excess <= 0 {
+			break
+		}
+		if name == keep {
+			continue
+		}
+		closing = append(closing, r.evictLocked(name)...)
+		excess--
+	}
+	return closing
+}
+
+// evictIdle 淘汰在 timeout 内没有写过日志的 logger，返回淘汰数量。
+func (r *loggerRegistry) evictIdle(now time.Time, timeout time.Duration) int {
+	deadline := now.Add(-timeout).UnixNano()
+	r.mu.Lock()
+	var closing []*resilientWriter
+	evicted := 0
+	for _, name := range r.activeLocked() {
+		if r.cores[name].root.lastUse.Load() >= deadline {
+			break
+		}
+		closing = append(closing, r.evictLocked(name)...)
+		evicted++
+	}
+	r.mu.Unlock()
+
+	retireWriters(closing)
+	return evicted
+}
+
+// activeCount 返回当前持有文件的 logger 数量。
+func (r *loggerRegistry) activeCount() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	n := 0
+	for _, core := range r.cores {
+		if _, dormant := core.inner().(*dormantCore); !dormant {
+			n++
+		}
+	}
+	return n
+}
+
+// closeWriters 刷新并关闭文件 writer。
+func closeWriters(writers []*resilientWriter) {
+	for _, w := range writers {
+		_ = w.close()
+	}
+}
+
+// retireWriters 关闭被淘汰 logger 的文件 writer，在途日志仍会写入原文件。
+func retireWriters(writers []*resilientWriter) {
+	for _, w := range writers {
+		_ = w.retire()
+	}
+}
+
+// idleSweeper 按 LoggerIdleTimeout 定期淘汰空闲 logger。
+type idleSweeper struct {
+	timeout time.Duration
+	stop    chan struct{}
+	once    sync.Once
+}
+
+// run 每半个超时时间检查一次。
+func (s *idleSweeper) run(r *loggerRegistry) {
+	interval := s.timeout / 2
+	if interval < minIdleSweepInterval {
+		interval = minIdleSweepInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case now := <-ticker.C:
+			r.evictIdle(now, s.timeout)
+		}
+	}
+}
+
+// halt 停止检查。
+func (s *idleSweeper) halt() {
+	s.once.Do(func() { close(s.stop) })
+}
+
+// startIdleSweeper 按当前配置启动、重启或停止空闲淘汰。
+func (m *Manager) startIdleSweeper() {
+	timeout := m.getConfig().LoggerIdleTimeout
+	m.sweeperMu.Lock()
+	defer m.sweeperMu.Unlock()
+	if m.sweeper != nil && m.sweeper.timeout == timeout {
+		return
+	}
+	if m.sweeper != nil {
+		m.sweeper.halt()
+		m.sweeper = nil
+	}
+	if timeout <= 0 {
+		return
+	}
+	m.sweeper = &idleSweeper{timeout: timeout, stop: make(chan struct{})}
+	go m.sweeper.run(m.registry)
+}
+
+// stopIdleSweeper 停止空闲淘汰。
+func (m *Manager) stopIdleSweeper() {
+	m.sweeperMu.Lock()
+	defer m.sweeperMu.Unlock()
+	if m.sweeper != nil {
+		m.sweeper.halt()
+		m.sweeper = nil
+	}
+}
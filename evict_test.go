@@ -0,0 +1,146 @@
+package zlog
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// TestMaxLoggersEvictsLeastRecentlyUsed 测试超过 MaxLoggers 时关闭最久未使用的 logger，再次使用时自动重建
+func TestMaxLoggersEvictsLeastRecentlyUsed(t *testing.T) {
+	mgr, tmpDir := newTestManager(t, WithMaxLoggers(2))
+	tenantA := mgr.Logger("tenant_a")
+	tenantA.Info("a1")
+	time.Sleep(2 * time.Millisecond)
+	mgr.Logger("tenant_b").Info("b1")
+	time.Sleep(2 * time.Millisecond)
+	mgr.Logger("tenant_c").Info("c1")
+
+	stats := mgr.Stats()
+	if _, kept := stats.Loggers["tenant_a"]; stats.ActiveLoggers != 2 || stats.Evictions != 1 || kept {
+		t.Fatalf("expected tenant_a to be evicted and its metrics dropped, got active=%d evictions=%d loggers=%v", stats.ActiveLoggers, stats.Evictions, stats.Loggers)
+	}
+	for _, w := range mgr.Health().Writers {
+		if strings.Contains(w.Path, "tenant_a") {
+			t.Fatalf("evicted logger still holds %s", w.Path)
+		}
+	}
+
+	t.Log("已保存的 logger 再次写日志时自动重建，并淘汰下一个最久未使用的 logger")
+	time.Sleep(2 * time.Millisecond)
+	tenantA.Info("a2")
+	if content := readTodayLog(t, tmpDir, "tenant_a_info"); !strings.Contains(content, "a1") || !strings.Contains(content, "a2") {
+		t.Fatalf("revived logger lost entries: %q", content)
+	}
+	stats = mgr.Stats()
+	_, keptB := stats.Loggers["tenant_b"]
+	_, keptC := stats.Loggers["tenant_c"]
+	if stats.ActiveLoggers != 2 || stats.Evictions != 2 || keptB || !keptC {
+		t.Fatalf("expected tenant_b to be evicted next, got %+v", stats.Loggers)
+	}
+}
+
+// TestIdleLoggerEviction 测试空闲超时后关闭文件，With 派生的 logger 重建后保留字段
+func TestIdleLoggerEviction(t *testing.T) {
+	mgr, tmpDir := newTestManager(t, WithLoggerIdleTimeout(50*time.Millisecond))
+	child := mgr.Logger("tenant_x").With("tenant", "x")
+	child.Info("first")
+	if mgr.Stats().ActiveLoggers != 1 {
+		t.Fatal("logger evicted before the timeout")
+	}
+	waitFor(t, func() bool { return mgr.Stats().ActiveLoggers == 0 })
+
+	child.Info("second")
+	content := readTodayLog(t, tmpDir, "tenant_x_info")
+	if strings.Count(content, `"tenant":"x"`) != 2 || !strings.Contains(content, "second") {
+		t.Fatalf("revived child logger lost fields: %q", content)
+	}
+
+	var buf strings.Builder
+	_ = mgr.Stats().WritePrometheus(&buf)
+	if !strings.Contains(buf.String(), "zlog_logger_evictions_total 1\n") {
+		t.Fatalf("missing eviction metric:\n%s", buf.String())
+	}
+}
+
+// TestEvictionBoundsRegistry 测试大量短期 logger 轮流写入时，注册表与按名称的指标不会随名称数量增长
+func TestEvictionBoundsRegistry(t *testing.T) {
+	mgr, tmpDir := newTestManager(t, WithMaxLoggers(2))
+	stored := mgr.Logger("tenant_0")
+	for i := 0; i < 50; i++ {
+		mgr.Logger(fmt.Sprintf("tenant_%d", i)).Info("entry")
+	}
+
+	stats := mgr.Stats()
+	// 共享错误文件的指标以 ErrorLoggerName 单独计
+	if stats.ActiveLoggers != 2 || len(stats.Loggers) > 3 || stats.Evictions != 48 {
+		t.Fatalf("expected the registry to stay bounded, got active=%d evictions=%d loggers=%d", stats.ActiveLoggers, stats.Evictions, len(stats.Loggers))
+	}
+	if names := mgr.registry.names(); len(names) != 2 {
+		t.Fatalf("expected evicted names to be removed, got %v", names)
+	}
+
+	t.Log("保存的 logger 与重新获取的同名 logger 写入同一文件，只登记一份")
+	mgr.Logger("tenant_0").Info("fresh")
+	stored.Info("stored")
+	if content := readTodayLog(t, tmpDir, "tenant_0_info"); !strings.Contains(content, "fresh") || !strings.Contains(content, "stored") {
+		t.Fatalf("stored logger lost entries: %q", content)
+	}
+	if n := mgr.Stats().ActiveLoggers; n != 2 {
+		t.Fatalf("expected 2 active loggers, got %d", n)
+	}
+}
+
+// TestEvictionKeepsInFlightEntries 测试并发写入时被淘汰 logger 的在途日志仍写入原文件，不会进入兜底
+func TestEvictionKeepsInFlightEntries(t *testing.T) {
+	var failures int64
+	mgr, tmpDir := newTestManager(t, WithMaxLoggers(2),
+		WithWriteFallback(FallbackDiscard),
+		WithErrorHandler(func(WriteError) { atomic.AddInt64(&failures, 1) }))
+
+	t.Log("Check 之后、Write 之前被淘汰")
+	ce := mgr.ZapLogger("tenant_0").Check(zapcore.InfoLevel, "entry")
+	mgr.Logger("tenant_1").Info("evict")
+	mgr.Logger("tenant_2").Info("evict")
+	if mgr.Stats().Evictions != 1 {
+		t.Fatal("expected tenant_0 to be evicted")
+	}
+	ce.Write()
+
+	t.Log("并发写入的租户数超过 MaxLoggers")
+	const tenants, lines = 8, 1000
+	var wg sync.WaitGroup
+	for i := 0; i < tenants; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			logger := mgr.Logger(fmt.Sprintf("tenant_%d", i))
+			for j := 0; j < lines; j++ {
+				logger.Info("entry")
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt64(&failures); n != 0 {
+		t.Fatalf("expected no write failures during eviction, got %d", n)
+	}
+	for i := 0; i < tenants; i++ {
+		content := readTodayLog(t, tmpDir, fmt.Sprintf("tenant_%d_info", i))
+		want := lines
+		if i == 0 {
+			want++
+		}
+		if got := strings.Count(content, `"message":"entry"`); got != want {
+			t.Fatalf("tenant_%d wrote %d of %d entries", i, got, want)
+		}
+	}
+	if stats := mgr.Stats(); stats.ActiveLoggers > 2 {
+		t.Fatalf("expected at most 2 active loggers, got %d", stats.ActiveLoggers)
+	}
+}
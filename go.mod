@@ -3,10 +3,11 @@ module github.com/Xuzan9396/zlog
 go 1.16
 
 require (
-	github.com/fsnotify/fsnotify v1.9.0 // indirect
+	github.com/fsnotify/fsnotify v1.9.0
 	github.com/jonboulle/clockwork v0.2.2 // indirect
 	github.com/lestrrat-go/file-rotatelogs v2.4.0+incompatible
 	github.com/lestrrat-go/strftime v1.0.5 // indirect
-	github.com/nxadm/tail v1.4.11 // indirect
+	github.com/nxadm/tail v1.4.11
 	go.uber.org/zap v1.19.1
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,74 @@
+package zlog
+
+import (
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// parentLoggerName 返回点分名称的上一级，例如 order.payment.refund -> order.payment。
+func parentLoggerName(name string) (string, bool) {
+	i := strings.LastIndexByte(name, '.')
+	if i <= 0 {
+		return "", false
+	}
+	return name[:i], true
+}
+
+// loggerLevel 返回名称自身或最近祖先的等级覆盖。
+func (cfg Config) loggerLevel(name string) (zapcore.Level, bool) {
+	return nearestLevel(cfg.LoggerLevels, name)
+}
+
+// nearestLevel 在 levels 中查找名称自身或最近祖先的等级。
+func nearestLevel(levels map[string]zapcore.Level, name string) (zapcore.Level, bool) {
+	if len(levels) == 0 {
+		return 0, false
+	}
+	for {
+		if level, ok := levels[name]; ok {
+			return level, true
+		}
+		parent, ok := parentLoggerName(name)
+		if !ok {
+			return 0, false
+		}
+		name = parent
+	}
+}
+
+// fileOwner 返回 logger 实际写入的文件前缀：名称自身或最近祖先中 SharedFiles 的设置决定，
+// 设置为 true 时写入该祖先的文件，为 false 或没有设置时写入自己的文件。
+func (cfg Config) fileOwner(name string) string {
+	if len(cfg.SharedFiles) == 0 {
+		return name
+	}
+	for current := name; ; {
+		if shared, ok := cfg.SharedFiles[current]; ok {
+			if shared {
+				return current
+			}
+			return name
+		}
+		parent, ok := parentLoggerName(current)
+		if !ok {
+			return name
+		}
+		current = parent
+	}
+}
+
+// sharesFile 判断文件是否可能包含多个 logger 的日志，此时文件输出需要带上 f 字段。
+func (cfg Config) sharesFile(name, owner string) bool {
+	return owner != name || cfg.SharedFiles[name]
+}
+
+// levelFor 返回 logger 生效的等级：有前缀覆盖时使用固定等级，否则跟随全局等级；
+// SetLoggerLevelFor 设置的临时等级优先于两者。
+func (r *loggerRegistry) levelFor(cfg Config, name string) zapcore.LevelEnabler {
+	var base zapcore.LevelEnabler = r.level
+	if level, ok := cfg.loggerLevel(name); ok {
+		base = level
+	}
+	return escalatedLevel{registry: r, name: name, base: base}
+}
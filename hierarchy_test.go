@@ -0,0 +1,114 @@
+package zlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// TestHierarchicalLevels 测试点分名称继承最近祖先的等级覆盖
+func TestHierarchicalLevels(t *testing.T) {
+	cfg := newDefaultConfig()
+	applyOptions(&cfg,
+		WithLoggerLevel("order", zapcore.WarnLevel),
+		WithLoggerLevel("order.payment", zapcore.DebugLevel),
+	)
+
+	cases := map[string]struct {
+		level zapcore.Level
+		ok    bool
+	}{
+		"order":                {zapcore.WarnLevel, true},
+		"order.shipping":       {zapcore.WarnLevel, true},
+		"order.payment":        {zapcore.DebugLevel, true},
+		"order.payment.refund": {zapcore.DebugLevel, true},
+		"orders":               {0, false},
+		"api":                  {0, false},
+	}
+	for name, want := range cases {
+		level, ok := cfg.loggerLevel(name)
+		if ok != want.ok || level != want.level {
+			t.Fatalf("%s: got %v/%v, want %v/%v", name, level, ok, want.level, want.ok)
+		}
+	}
+}
+
+// TestHierarchicalLevelFiltering 测试等级覆盖作用于全部后代，未覆盖的 logger 跟随全局等级
+func TestHierarchicalLevelFiltering(t *testing.T) {
+	mgr, tmpDir := newTestManager(t, WithLevel(zapcore.InfoLevel),
+		WithLoggerLevel("order", zapcore.WarnLevel),
+		WithLoggerLevel("order.payment", zapcore.DebugLevel),
+	)
+	mgr.Logger("order.shipping").Info("shipping info")
+	mgr.Logger("order.shipping").Warn("shipping warn")
+	mgr.Logger("order.payment.refund").Debug("refund debug")
+	mgr.Logger("api").Debug("api debug")
+	mgr.Logger("api").Info("api info")
+
+	if content := readTodayLog(t, tmpDir, "order.shipping_info"); strings.Contains(content, "shipping info") || !strings.Contains(content, "shipping warn") {
+		t.Fatalf("order.shipping should inherit warn from order: %q", content)
+	}
+	if content := readTodayLog(t, tmpDir, "order.payment.refund_info"); !strings.Contains(content, "refund debug") {
+		t.Fatalf("order.payment.refund should inherit debug: %q", content)
+	}
+	if content := readTodayLog(t, tmpDir, "api_info"); strings.Contains(content, "api debug") || !strings.Contains(content, "api info") {
+		t.Fatalf("api should follow the global level: %q", content)
+	}
+}
+
+// TestSharedFileRouting 测试后代写入祖先文件并带 f 字段，可单独改回写自己的文件
+func TestSharedFileRouting(t *testing.T) {
+	mgr, tmpDir := newTestManager(t, WithMaxLoggers(3),
+		WithSharedFile("order", true),
+		WithSharedFile("order.audit", false),
+	)
+	mgr.Logger("order").Info("from order")
+	mgr.Logger("order.payment.refund").Info("from refund")
+	mgr.Logger("order.audit.daily").Info("from audit")
+
+	content := readTodayLog(t, tmpDir, "order_info")
+	if !strings.Contains(content, `"f":"order.payment.refund"`) || !strings.Contains(content, `"f":"order"`) {
+		t.Fatalf("shared file missing entries or f field: %q", content)
+	}
+	if strings.Contains(content, "from audit") {
+		t.Fatalf("order.audit.* should use its own file: %q", content)
+	}
+	if content := readTodayLog(t, tmpDir, "order.audit.daily_info"); !strings.Contains(content, "from audit") || strings.Contains(content, `"f"`) {
+		t.Fatalf("own file should not carry f: %q", content)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "order.payment.refund_info.log")); !os.IsNotExist(err) {
+		t.Fatalf("routed child must not open its own file: %v", err)
+	}
+
+	t.Log("共享文件只打开一个 writer，淘汰子 logger 不影响父 logger")
+	paths := map[string]int{}
+	for _, w := range mgr.Health().Writers {
+		paths[w.Path]++
+	}
+	if paths[logFilePath("%s_info.log", "order")] != 1 {
+		t.Fatalf("expected one shared writer, got %v", paths)
+	}
+	time.Sleep(2 * time.Millisecond)
+	mgr.Logger("order").Info("touch order")
+	mgr.Logger("order.audit.daily").Info("touch audit")
+	time.Sleep(2 * time.Millisecond)
+	mgr.Logger("api").Info("evicts refund")
+	if stats := mgr.Stats(); stats.Evictions != 1 {
+		t.Fatalf("expected order.payment.refund to be evicted, got %d", stats.Evictions)
+	}
+	paths = map[string]int{}
+	for _, w := range mgr.Health().Writers {
+		paths[w.Path]++
+	}
+	if paths[logFilePath("%s_info.log", "order")] != 1 {
+		t.Fatalf("evicting a child closed the shared file: %v", paths)
+	}
+	mgr.Logger("order").Info("still open")
+	if content := readTodayLog(t, tmpDir, "order_info"); !strings.Contains(content, "still open") {
+		t.Fatalf("parent lost its file: %q", content)
+	}
+}
@@ -0,0 +1,90 @@
+package zlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// readTodayLog 读取 logs 目录下指定前缀当天的日志内容。
+func readTodayLog(t *testing.T, dir, prefix string) string {
+	t.Helper()
+	path := filepath.Join(dir, prefix+time.Now().In(location).Format("2006-01-02")+".log")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+// newTestManager 创建写入临时目录、不启动自动清理的 Manager，测试结束时关闭并恢复全局日志目录。
+func newTestManager(t *testing.T, options ...LogOption) (*Manager, string) {
+	t.Helper()
+	dir := t.TempDir()
+	origDir := logDir()
+	mgr := NewManager(append([]LogOption{WithLogDir(dir), WithAutoCleanup(false)}, options...)...)
+	t.Cleanup(func() {
+		_ = mgr.Close()
+		setLogDir(origDir)
+	})
+	return mgr, dir
+}
+
+func TestSplitLevelFiles(t *testing.T) {
+	mgr, tmpDir := newTestManager(t, WithLevel(zapcore.DebugLevel), WithSplitLevels(true))
+	logger := mgr.Logger("api")
+	logger.Debug("debug entry")
+	logger.Info("info entry")
+	logger.Warn("warn entry")
+	logger.Error("error entry")
+
+	cases := map[string]string{
+		"api_debug": "debug entry",
+		"api_info":  "info entry",
+		"api_warn":  "warn entry",
+		"api_error": "error entry",
+	}
+	for prefix, want := range cases {
+		content := readTodayLog(t, tmpDir, prefix)
+		if strings.Count(content, "\n") != 1 || !strings.Contains(content, want) {
+			t.Fatalf("%s: expected only %q, got %q", prefix, want, content)
+		}
+	}
+
+	// error 级别同时进入共享的错误文件
+	if content := readTodayLog(t, tmpDir, mgr.getConfig().ErrorLoggerName); !strings.Contains(content, "error entry") {
+		t.Fatalf("shared error file missing entry: %q", content)
+	}
+}
+
+func TestCustomLevelFiles(t *testing.T) {
+	mgr, tmpDir := newTestManager(t, WithLevelFiles(
+		LevelFile{Name: "all", Min: zapcore.DebugLevel, Max: zapcore.FatalLevel},
+		LevelFile{Name: "alert", Min: zapcore.WarnLevel, Max: zapcore.FatalLevel},
+	))
+	logger := mgr.Logger("svc")
+	logger.Debug("dropped by level")
+	logger.Info("info entry")
+	logger.Warn("warn entry")
+
+	if content := readTodayLog(t, tmpDir, "svc_all"); strings.Count(content, "\n") != 2 || strings.Contains(content, "dropped") {
+		t.Fatalf("svc_all: unexpected content %q", content)
+	}
+	if content := readTodayLog(t, tmpDir, "svc_alert"); strings.Count(content, "\n") != 1 || !strings.Contains(content, "warn entry") {
+		t.Fatalf("svc_alert: unexpected content %q", content)
+	}
+}
+
+func TestSplitLevelFilesShareErrorFile(t *testing.T) {
+	// 默认 logger "log" 拆分出的 log_error.log 与共享错误文件同名，只应写入一次
+	mgr, tmpDir := newTestManager(t, WithDefaultName("log"), WithSplitLevels(true))
+	mgr.Logger().Error("only once")
+
+	if content := readTodayLog(t, tmpDir, "log_error"); strings.Count(content, "only once") != 1 {
+		t.Fatalf("expected a single error entry, got %q", content)
+	}
+}
@@ -2,7 +2,9 @@ package zlog
 
 import (
 	"log"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -126,8 +128,8 @@ func TestResolveLevelMapping(t *testing.T) {
 }
 
 func TestManagerIsolation(t *testing.T) {
-	mgrA := NewManager()
-	mgrB := NewManager()
+	mgrA, _ := newTestManager(t)
+	mgrB, _ := newTestManager(t)
 
 	mgrA.SetLog(ENV_DEBUG)
 	if mgrA.getConfig().Env != ENV_DEBUG {
@@ -153,7 +155,7 @@ func TestManagerIsolation(t *testing.T) {
 }
 
 func TestWithDefaultNameOption(t *testing.T) {
-	mgr := NewManager(WithDefaultName("log"), WithErrorName("log_err"))
+	mgr, _ := newTestManager(t, WithDefaultName("log"), WithErrorName("log_err"))
 	cfg := mgr.getConfig()
 	if cfg.DefaultLoggerName != "log" {
 		t.Fatalf("expected default name log, got %s", cfg.DefaultLoggerName)
@@ -167,3 +169,43 @@ func TestWithDefaultNameOption(t *testing.T) {
 		t.Fatal("expected logger instance")
 	}
 }
+
+func TestPerLoggerErrorFile(t *testing.T) {
+	mgr, tmpDir := newTestManager(t,
+		WithPerLoggerErrorFile(true),
+		WithLoggerErrorFile("auth", false),
+	)
+	mgr.Logger("payment").Error("payment failed")
+	mgr.Logger("payment").Info("payment info")
+	mgr.Logger("auth").Error("auth failed")
+
+	content := readTodayLog(t, tmpDir, "payment_error")
+	if !strings.Contains(content, "payment failed") || strings.Contains(content, "payment info") {
+		t.Fatalf("payment_error: unexpected content %q", content)
+	}
+
+	// auth 被单独关闭，只写共享错误文件
+	today := time.Now().In(location).Format("2006-01-02")
+	if _, err := os.Stat(filepath.Join(tmpDir, "auth_error"+today+".log")); !os.IsNotExist(err) {
+		t.Fatalf("auth_error should not exist, err=%v", err)
+	}
+	shared := readTodayLog(t, tmpDir, mgr.getConfig().ErrorLoggerName)
+	if !strings.Contains(shared, "payment failed") || !strings.Contains(shared, "auth failed") {
+		t.Fatalf("shared error file missing entries: %q", shared)
+	}
+}
+
+func TestLoggerErrorFileOverrideIsolation(t *testing.T) {
+	base := newDefaultConfig()
+	applyOptions(&base, WithLoggerErrorFile("payment", true))
+
+	next := cloneConfig(base)
+	applyOptions(&next, WithLoggerErrorFile("payment", false))
+
+	if !base.loggerErrorFileEnabled("payment") {
+		t.Fatal("override on a copy must not leak into the original config")
+	}
+	if next.loggerErrorFileEnabled("payment") {
+		t.Fatal("expected override to disable payment error file")
+	}
+}
@@ -0,0 +1,27 @@
+package zlog
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+// TestMain 在临时目录中运行测试，全局实例默认的 logs 目录不会写入仓库。
+func TestMain(m *testing.M) {
+	os.Exit(runInTempDir(m))
+}
+
+func runInTempDir(m *testing.M) int {
+	dir, err := os.MkdirTemp("", "zlog-test")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Chdir(dir); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	setLogDir(workingLogsDir())
+	return m.Run()
+}
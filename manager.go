@@ -2,9 +2,13 @@ package zlog
 
 import (
 	"errors"
+	"fmt"
+	"net/http"
+	"os"
 	"runtime/debug"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
@@ -15,10 +19,22 @@ import (
 type Manager struct {
 	cfgMu       sync.RWMutex
 	cfg         Config
+	base        Config      // 内置默认值与环境变量，切换环境时从这里重新构建
+	options     []LogOption // NewManager 的选项，切换环境时重新应用
+	configPath  string      // 最近一次成功应用的配置文件，供 SIGHUP 重新读取
 	level       zap.AtomicLevel
 	registry    *loggerRegistry
+	cleanupMu   sync.Mutex // 保护 cleanupTask 与 cleanupOnce，配置监听与 SIGHUP 会并发重启清理任务
 	cleanupTask *CleanupTask
 	cleanupOnce sync.Once
+	emergency   atomic.Bool // 紧急清理是否正在进行
+	sweeperMu   sync.Mutex
+	sweeper     *idleSweeper // LoggerIdleTimeout 大于 0 时定期淘汰空闲 logger
+	escMu       sync.Mutex
+	escalations map[string]*escalation // SetLevelFor 设置的临时等级，全局等级的键为空
+	renameMu    sync.Mutex
+	renamed     map[string][]string // 替换不安全字符后的名称 -> 已提示过的原始名称，用于提示改名与冲突
+	renameNotes int
 }
 
 var (
@@ -34,18 +50,60 @@ func getDefaultManager() *Manager {
 }
 
 // NewManager 创建一个新的日志管理器，可选地应用配置选项。
+// 不校验配置，无效字段按原有方式处理；需要报告错误时使用 NewManagerE。
 func NewManager(options ...LogOption) *Manager {
-	cfg := newDefaultConfig()
+	base := newDefaultConfig()
+	cfg := cloneConfig(base)
 	applyOptions(&cfg, options...)
 
 	setLogDir(cfg.LogDir)
 	_ = ensureDir(cfg.LogDir)
+	return newManager(cfg, base, options)
+}
 
+// NewManagerE 与 NewManager 相同，但会校验环境变量与配置，并在日志目录无法创建时返回错误。
+// 返回的 *ConfigError 列出全部无效字段，此时不会创建实例。
+func NewManagerE(options ...LogOption) (*Manager, error) {
+	base := builtinConfig()
+	var problems []string
+	for _, err := range applyEnv(&base, os.LookupEnv) {
+		problems = append(problems, err.Error())
+	}
+	cfg := cloneConfig(base)
+	applyOptions(&cfg, options...)
+	if err := cfg.check(problems); err != nil {
+		return nil, err
+	}
+
+	setLogDir(cfg.LogDir)
+	return newManager(cfg, base, options), nil
+}
+
+// check 校验配置并确认日志目录可用，extra 为调用方已发现的问题。
+func (cfg Config) check(extra []string) error {
+	problems := append(extra, cfg.problems()...)
+	if len(problems) == 0 && !cfg.ConsoleOnly {
+		if err := ensureDir(cfg.LogDir); err != nil {
+			problems = append(problems, fmt.Sprintf("log dir: %v", err))
+		}
+	}
+	if len(problems) > 0 {
+		return &ConfigError{Problems: problems}
+	}
+	return nil
+}
+
+// newManager 按已确定的配置创建实例，base 与 options 是得到 cfg 的起点与选项。
+func newManager(cfg, base Config, options []LogOption) *Manager {
 	mgr := &Manager{
-		cfg:   cfg,
-		level: zap.NewAtomicLevelAt(cfg.Level),
+		cfg:     cfg,
+		base:    base,
+		options: options,
+		level:   zap.NewAtomicLevelAt(cfg.Level),
 	}
 	mgr.registry = newLoggerRegistry(&mgr.level, mgr.getConfig)
+	mgr.registry.onDiskFault = mgr.handleDiskFault
+	mgr.startIdleSweeper()
 
 	// 延迟启动清理任务，避免初始化循环依赖
 	if cfg.AutoCleanup {
@@ -58,24 +116,66 @@ func NewManager(options ...LogOption) *Manager {
 	return mgr
 }
 
-// SetLog 应用环境和选项到当前管理器。
+// SetLog 应用环境和选项到当前管理器，显式指定的 env 优先于 ZLOG_LEVEL 的全局等级。
+// 不校验配置，无效字段按原有方式处理；需要报告错误时使用 SetLogE。
 func (m *Manager) SetLog(env Env, options ...LogOption) {
 	m.cfgMu.Lock()
-	cfg := m.cfg
+	cfg := m.nextConfigLocked(m.cfg, env, true, options)
+	m.cfg = cfg
+	m.cfgMu.Unlock()
+
+	_ = ensureDir(cfg.LogDir)
+	m.applyConfig(cfg)
+}
+
+// SetLogE 与 SetLog 相同，但会先校验新配置并确认日志目录可用；
+// 返回的 *ConfigError 列出全部无效字段，此时当前配置保持不变。
+func (m *Manager) SetLogE(env Env, options ...LogOption) error {
+	return m.setLogFrom(env, true, options...)
+}
+
+// setLogFrom 与 SetLogE 相同；explicit 为 false 表示 env 沿用当前值，此时保留 ZLOG_LEVEL 的全局等级。
+func (m *Manager) setLogFrom(env Env, explicit bool, options ...LogOption) error {
+	m.cfgMu.Lock()
+	cfg := m.nextConfigLocked(m.cfg, env, explicit, options)
+	if err := cfg.check(nil); err != nil {
+		m.cfgMu.Unlock()
+		return err
+	}
+	m.cfg = cfg
+	m.cfgMu.Unlock()
+
+	m.applyConfig(cfg)
+	return nil
+}
+
+// nextConfigLocked 计算在 from 上应用 env 与 options 后的配置，调用方需持有 m.cfgMu。
+// 切换到其他环境时从内置默认值、环境变量与 NewManager 选项重新构建，上一个 profile 的选项不会残留；
+// 环境不变时在 from 上叠加。
+func (m *Manager) nextConfigLocked(from Config, env Env, explicit bool, options []LogOption) Config {
+	cfg := cloneConfig(from)
+	if env != from.Env {
+		cfg = cloneConfig(m.base)
+		options = append(append([]LogOption(nil), m.options...), options...)
+	}
 	cfg.Env = env
 	cfg.levelOverride = false
+	if explicit {
+		cfg.envLevelSet = false
+	}
 	applyOptions(&cfg, options...)
-	m.cfg = cfg
-	m.cfgMu.Unlock()
+	return cfg
+}
 
+// applyConfig 让已保存的新配置生效。
+func (m *Manager) applyConfig(cfg Config) {
 	// 应用日志目录变更
 	setLogDir(cfg.LogDir)
-	_ = ensureDir(cfg.LogDir)
-	m.registry.resetErrorWriter()
 
-	m.level.SetLevel(cfg.Level)
-	// 清除 logger 缓存，强制重新创建以应用新的输出配置
+	m.syncLevel()
+	// 按新配置重建 core，调用方已持有的 logger 立即生效
 	m.registry.reset()
+	m.startIdleSweeper()
 
 	// 重新启动清理任务（如果配置改变）
 	m.startCleanupTask()
@@ -87,6 +187,7 @@ func (m *Manager) UpdateRetention(withMaxAge, withRotationTime int) {
 	cfg := m.cfg
 	cfg.WithMaxAge = withMaxAge
 	cfg.WithRotationTime = withRotationTime
+	cfg.RotationPeriod = 0
 	m.cfg = cfg
 	m.cfgMu.Unlock()
 }
@@ -100,25 +201,56 @@ func (m *Manager) SetLevel(level zapcore.Level) {
 	m.cfg = cfg
 	m.cfgMu.Unlock()
 
-	m.level.SetLevel(level)
-	// 清除 logger 缓存，强制重新创建以应用新的输出配置
+	m.syncLevel()
+	// 按新配置重建 core，调用方已持有的 logger 立即生效
 	m.registry.reset()
 }
 
 // Logger 返回指定名称的 SugaredLogger。
 func (m *Manager) Logger(fileNameArr ...string) *zap.SugaredLogger {
+	return m.logger(fileNameArr...).sugar
+}
+
+// ZapLogger 返回指定名称的 *zap.Logger，与 Logger 共享 core 和调用栈设置，适合热点路径的强类型字段日志。
+func (m *Manager) ZapLogger(fileNameArr ...string) *zap.Logger {
+	return m.logger(fileNameArr...).zap
+}
+
+// logger 解析名称与调用栈深度参数，返回缓存的 logger。
+// 无法安全用作文件名的名称会替换不安全字符，保证日志始终写在 LogDir 内。
+func (m *Manager) logger(fileNameArr ...string) *loggerPair {
+	skip := callerSkip(fileNameArr)
+	if len(fileNameArr) == 0 || fileNameArr[0] == "" {
+		return m.registry.getOrCreate(m.getConfig().DefaultLoggerName, skip)
+	}
+
+	// 缓存中只有校验过的名称，命中时无需再次校验
+	name := fileNameArr[0]
+	if pair, ok := m.registry.lookup(name, skip); ok {
+		if pair.renamed.Load() {
+			m.noteRename(name, name)
+		}
+		return pair
+	}
 	cfg := m.getConfig()
-	name := cfg.DefaultLoggerName
-	if len(fileNameArr) > 0 && fileNameArr[0] != "" {
-		name = fileNameArr[0]
+	resolved, err := cfg.resolveLoggerName(name)
+	if err != nil {
+		resolved = SanitizeLoggerName(name, cfg.maxLoggerNameLength())
+	}
+	m.noteRename(name, resolved)
+	pair := m.registry.getOrCreate(resolved, skip)
+	if resolved != name {
+		pair.renamed.Store(true)
 	}
+	return pair
+}
 
-	var skip uint8
+// callerSkip 根据可选的第二个参数返回额外跳过的调用栈层数。
+func callerSkip(fileNameArr []string) uint8 {
 	if len(fileNameArr) > 1 {
-		skip = 1
+		return 1
 	}
-
-	return m.registry.getOrCreate(name, skip)
+	return 0
 }
 
 // F 是 Logger 的别名，方便与全局 API 对齐。
@@ -136,8 +268,8 @@ func (m *Manager) Sync(fileName string) error {
 	if fileName == "" {
 		return errors.New("文件错误")
 	}
-	if logger, ok := m.registry.get(fileName); ok && logger != nil {
-		return logger.Sync()
+	if core, ok := m.registry.get(fileName); ok && core != nil {
+		return core.Sync()
 	}
 	return nil
 }
@@ -185,7 +317,7 @@ func (m *Manager) SetConsoleOnly(consoleOnly bool) {
 	m.cfg = cfg
 	m.cfgMu.Unlock()
 
-	// 清除 logger 缓存，强制重新创建以应用新的输出配置
+	// 按新配置重建 core，调用方已持有的 logger 立即生效
 	m.registry.reset()
 }
 
@@ -193,44 +325,153 @@ func (m *Manager) SetConsoleOnly(consoleOnly bool) {
 func (m *Manager) startCleanupTask() {
 	cfg := m.getConfig()
 
+	m.cleanupMu.Lock()
+	defer m.cleanupMu.Unlock()
+
 	// 如果禁用自动清理，停止现有任务
 	if !cfg.AutoCleanup {
-		m.StopCleanupTask()
+		m.stopCleanupTaskLocked()
 		return
 	}
 
 	// 只初始化一次
 	m.cleanupOnce.Do(func() {
-		m.cleanupTask = newCleanupTask(cfg.CleanupInterval)
+		m.cleanupTask = m.newCleanupTask(cfg)
 		m.cleanupTask.Start()
 	})
 
 	// 如果已经存在任务，检查是否需要更新
 	if m.cleanupTask != nil {
-		// 如果间隔改变，重启任务
-		if m.cleanupTask.interval != cfg.CleanupInterval {
-			m.StopCleanupTask()
+		// 调度方式改变或任务已停止时，重启任务
+		if m.cleanupTask.key != cfg.cleanupScheduleKey() || m.cleanupTask.stopped() {
+			m.stopCleanupTaskLocked()
 			m.cleanupOnce = sync.Once{} // 重置 Once
-			m.cleanupTask = newCleanupTask(cfg.CleanupInterval)
+			m.cleanupTask = m.newCleanupTask(cfg)
 			m.cleanupTask.Start()
 		}
 	}
 }
 
+// newCleanupTask 按配置创建清理任务，调度表达式无效时提示并退回固定间隔。
+func (m *Manager) newCleanupTask(cfg Config) *CleanupTask {
+	task, err := newScheduledCleanupTask(cfg, m.runScheduledCleanup)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v, falling back to cleanup interval %s\n", err, cfg.CleanupInterval)
+	}
+	return task
+}
+
 // CleanupLogs 手动触发一次日志清理
 func (m *Manager) CleanupLogs() {
-	clearLog()
+	m.runScheduledCleanup()
+}
+
+// CleanupLogsReport 按当前配置清理日志目录并返回报告，dryRun 时只统计将被删除的文件。
+func (m *Manager) CleanupLogsReport(dryRun bool) (CleanupReport, error) {
+	cfg := m.getConfig()
+	report, err := runCleanup(cleanupOptions{
+		dir:            cfg.LogDir,
+		maxAge:         cfg.WithMaxAge,
+		maxFiles:       cfg.MaxFiles,
+		dryRun:         dryRun,
+		archiver:       cfg.Archiver,
+		archiveTimeout: cfg.ArchiveTimeout,
+		prefixes:       m.cleanupPrefixes(cfg),
+	})
+	if !dryRun && err == nil {
+		m.registry.metrics.recordCleanup(report)
+	}
+	return *report, err
+}
+
+// handleDiskFault 在磁盘已满时后台执行一次紧急清理，同一时间只运行一次。
+func (m *Manager) handleDiskFault(fault DiskFault, _ error) {
+	cfg := m.getConfig()
+	if fault != DiskFull || cfg.EmergencyFreeBytes <= 0 {
+		return
+	}
+	if !m.emergency.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer m.emergency.Store(false)
+		m.emergencyCleanup(cfg)
+	}()
+}
+
+// emergencyCleanup 释放磁盘空间并记录结果；磁盘已满时不写清理日志通道，只触发回调。
+func (m *Manager) emergencyCleanup(cfg Config) {
+	report, err := runEmergencyCleanup(cleanupOptions{dir: cfg.LogDir, archiver: cfg.Archiver, archiveTimeout: cfg.ArchiveTimeout, prefixes: m.cleanupPrefixes(cfg)}, cfg.EmergencyFreeBytes)
+	if err != nil {
+		return
+	}
+	m.registry.metrics.recordCleanup(report)
+	if cfg.CleanupCallback != nil {
+		cfg.CleanupCallback(*report)
+	}
+}
+
+// cleanupPrefixes 返回目录中没有清单时本实例认领的文件前缀。
+func (m *Manager) cleanupPrefixes(cfg Config) map[string]bool {
+	return configuredPrefixes(cfg, m.registry.names())
+}
+
+// runScheduledCleanup 执行一次清理，并通过回调或指定日志通道报告结果。
+func (m *Manager) runScheduledCleanup() {
+	cfg := m.getConfig()
+	report, err := m.CleanupLogsReport(false)
+
+	if cfg.CleanupCallback != nil {
+		cfg.CleanupCallback(report)
+	}
+	if cfg.CleanupLogName == "" {
+		return
+	}
+
+	logger := m.Logger(cfg.CleanupLogName)
+	if err != nil {
+		logger.Warnw("zlog cleanup failed", "dir", report.Dir, "error", err)
+		return
+	}
+	for _, failure := range report.Failures {
+		logger.Warnw("zlog cleanup remove failed", "path", failure.Path, "error", failure.Err)
+	}
+	logger.Infow("zlog cleanup finished",
+		"dir", report.Dir,
+		"deleted", len(report.Deleted),
+		"symlinks", len(report.Symlinks),
+		"failures", len(report.Failures),
+		"bytes_reclaimed", report.BytesReclaimed,
+		"duration", report.Duration,
+	)
 }
 
 // StopCleanupTask 停止后台清理任务
 func (m *Manager) StopCleanupTask() {
+	m.cleanupMu.Lock()
+	defer m.cleanupMu.Unlock()
+	m.stopCleanupTaskLocked()
+}
+
+// stopCleanupTaskLocked 停止后台清理任务，调用方需持有 m.cleanupMu。
+func (m *Manager) stopCleanupTaskLocked() {
 	if m.cleanupTask != nil {
 		m.cleanupTask.Stop()
 	}
 }
 
+// Close 停止清理任务并刷新、关闭所有日志文件；之后的写入按 WriteFallback 兜底。
+func (m *Manager) Close() error {
+	m.StopCleanupTask()
+	m.stopIdleSweeper()
+	m.cancelEscalations()
+	return m.registry.close()
+}
+
 // IsCleanupRunning 返回清理任务是否正在运行
 func (m *Manager) IsCleanupRunning() bool {
+	m.cleanupMu.Lock()
+	defer m.cleanupMu.Unlock()
 	if m.cleanupTask == nil {
 		return false
 	}
@@ -256,7 +497,6 @@ func (m *Manager) SetLogDir(dir string) error {
 	m.cfgMu.Unlock()
 
 	m.registry.reset()
-	m.registry.resetErrorWriter()
 	return nil
 }
 
@@ -274,6 +514,11 @@ func SetLog(env Env, options ...LogOption) {
 	getDefaultManager().SetLog(env, options...)
 }
 
+// SetLogE 校验并应用到全局实例，配置无效时返回 *ConfigError 且不做任何修改。
+func SetLogE(env Env, options ...LogOption) error {
+	return getDefaultManager().SetLogE(env, options...)
+}
+
 // SetEnv 兼容旧行为，等价于 SetLog。
 func SetEnv(env string) {
 	getDefaultManager().SetLog(Env(env))
@@ -304,6 +549,31 @@ func CleanupLogs() {
 	getDefaultManager().CleanupLogs()
 }
 
+// CleanupLogsReport 按全局配置清理日志并返回报告，dryRun 时只统计不删除。
+func CleanupLogsReport(dryRun bool) (CleanupReport, error) {
+	return getDefaultManager().CleanupLogsReport(dryRun)
+}
+
+// GetStats 返回全局日志管道指标快照。
+func GetStats() Stats {
+	return getDefaultManager().Stats()
+}
+
+// GetHealth 返回全局实例文件 writer 的状态。
+func GetHealth() Health {
+	return getDefaultManager().Health()
+}
+
+// MetricsHandler 返回以 Prometheus 文本格式输出全局指标的 http.Handler。
+func MetricsHandler() http.Handler {
+	return getDefaultManager().MetricsHandler()
+}
+
+// Close 刷新并关闭全局实例的日志文件。
+func Close() error {
+	return getDefaultManager().Close()
+}
+
 // StopCleanupTask 停止全局后台清理任务。
 func StopCleanupTask() {
 	getDefaultManager().StopCleanupTask()
@@ -0,0 +1,115 @@
+package zlog
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// manifestFileName 是日志目录下记录 zlog 所创建文件前缀的清单文件。
+const manifestFileName = ".zlog-manifest"
+
+// manifestMu 串行化本进程内对清单文件的读写，并保护 manifestCache。
+var (
+	manifestMu    sync.Mutex
+	manifestCache = make(map[string]map[string]bool) // 按目录缓存已登记的前缀，清单只在目录首次登记时读取
+)
+
+// registerLogFile 将 writer 的链接文件（如 logs/api_info.log）登记到所在目录的清单中。
+// 已登记的前缀直接返回，新前缀只追加一行，不会每次打开 writer 都重新解析清单。
+func registerLogFile(linkPath string) error {
+	prefix := strings.TrimSuffix(filepath.Base(linkPath), ".log")
+	if prefix == "" {
+		return nil
+	}
+	dir := filepath.Dir(linkPath)
+
+	manifestMu.Lock()
+	defer manifestMu.Unlock()
+
+	owned, ok := manifestCache[dir]
+	if !ok {
+		if owned, _ = readManifest(dir); owned == nil {
+			owned = make(map[string]bool)
+		}
+		manifestCache[dir] = owned
+	}
+	if owned[prefix] {
+		return nil
+	}
+
+	path := filepath.Join(dir, manifestFileName)
+	fh, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	lines := []string{prefix}
+	if info, err := fh.Stat(); err == nil && info.Size() == 0 {
+		// 新建或被外部删除后重建的清单：写入说明并补回已登记的前缀
+		lines = append([]string{"# zlog manifest: file prefixes created by zlog, cleanup only touches these"}, lines...)
+		for p := range owned {
+			lines = append(lines, p)
+		}
+	}
+	_, err = fmt.Fprintln(fh, strings.Join(lines, "\n"))
+	if cerr := fh.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		owned[prefix] = true
+	}
+	return err
+}
+
+// configuredPrefixes 返回按配置与已创建的 logger 名称推导出的文件前缀：
+// <name>_info、<name>_error、<name>_<LevelFile.Name> 以及共享错误前缀。
+func configuredPrefixes(cfg Config, names []string) map[string]bool {
+	names = append(names, cfg.DefaultLoggerName, cfg.CleanupLogName)
+	for _, overrides := range []map[string]bool{cfg.SharedFiles, cfg.LoggerErrorFiles} {
+		for name := range overrides {
+			names = append(names, name)
+		}
+	}
+	for name := range cfg.LoggerLevels {
+		names = append(names, name)
+	}
+	suffixes := []string{"info", "error"}
+	for _, lf := range append(DefaultLevelFiles(), cfg.LevelFiles...) {
+		suffixes = append(suffixes, lf.Name)
+	}
+
+	prefixes := map[string]bool{cfg.ErrorLoggerName: true}
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		owner := cfg.fileOwner(name)
+		for _, suffix := range suffixes {
+			prefixes[owner+"_"+suffix] = true
+		}
+	}
+	return prefixes
+}
+
+// readManifest 读取目录下清单中登记的文件前缀，第二个返回值表示清单是否存在。
+func readManifest(dir string) (map[string]bool, bool) {
+	fh, err := os.Open(filepath.Join(dir, manifestFileName))
+	if err != nil {
+		return nil, false
+	}
+	defer fh.Close()
+
+	owned := make(map[string]bool)
+	scanner := bufio.NewScanner(fh)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		owned[line] = true
+	}
+	return owned, true
+}
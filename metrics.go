@@ -0,0 +1,358 @@
+package zlog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Stats 是日志管道指标的快照。
+type Stats struct {
+	Loggers       map[string]LoggerStats // 按 logger 名称（共享错误文件以 ErrorLoggerName 计）
+	Cleanup       CleanupStats
+	ActiveLoggers int               // 当前打开文件的 logger 数（不含已淘汰的）
+	Evictions     uint64            // 因 MaxLoggers 或空闲超时关闭 logger 文件的累计次数
+	Escalations   []LevelEscalation // 生效中的临时等级
+}
+
+// LoggerStats 是单个 logger 的指标。
+type LoggerStats struct {
+	Entries      map[zapcore.Level]uint64 // 按等级统计的日志条数
+	BytesWritten uint64                   // 写入文件的字节数
+	WriteErrors  uint64                   // 写文件失败次数
+	Dropped      uint64                   // 因写入失败而丢失的条数
+	Rotations    uint64                   // 文件切割次数
+	FilesDeleted uint64                   // 被清理删除的文件数
+	FileSize     int64                    // 当前正在写入文件的大小（字节）
+	Sampled      uint64                   // 被采样丢弃的条数
+}
+
+// CleanupStats 是日志清理的累计指标。
+type CleanupStats struct {
+	Runs           uint64
+	EmergencyRuns  uint64 // 磁盘已满触发的紧急清理次数
+	FilesDeleted   uint64
+	BytesReclaimed uint64
+	Failures       uint64
+	LastRun        time.Time
+}
+
+// levelCount 是 zap 等级的数量（Debug..Fatal）。
+const levelCount = int(zapcore.FatalLevel-zapcore.DebugLevel) + 1
+
+// loggerMetrics 记录单个 logger 的计数，registry 重建时保留。
+type loggerMetrics struct {
+	entries      [levelCount]atomic.Uint64
+	bytes        atomic.Uint64
+	writeErrors  atomic.Uint64
+	dropped      atomic.Uint64
+	rotations    atomic.Uint64
+	filesDeleted atomic.Uint64
+	dropSampled  atomic.Uint64
+
+	mu      sync.Mutex
+	writers []fileSizer // 当前使用的文件 writer，用于计算文件大小
+}
+
+// fileSizer 返回 writer 当前文件的大小。
+type fileSizer interface {
+	fileSize() int64
+}
+
+// metricsRegistry 汇总一个 Manager 的全部指标。
+type metricsRegistry struct {
+	mu        sync.Mutex
+	loggers   map[string]*loggerMetrics
+	cleanup   CleanupStats
+	evictions atomic.Uint64
+}
+
+func newMetricsRegistry() *metricsRegistry {
+	return &metricsRegistry{loggers: make(map[string]*loggerMetrics)}
+}
+
+// logger 返回（必要时创建）指定名称的指标。
+func (m *metricsRegistry) logger(name string) *loggerMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	lm, ok := m.loggers[name]
+	if !ok {
+		lm = &loggerMetrics{}
+		m.loggers[name] = lm
+	}
+	return lm
+}
+
+// evicted 累计一次淘汰，drop 为 true 时删除 name 的指标，避免按名称的指标随 logger 数量无限增长。
+// 被淘汰的 logger 重新使用后从零开始计数。
+func (m *metricsRegistry) evicted(name string, drop bool) {
+	m.evictions.Add(1)
+	if !drop {
+		return
+	}
+	m.mu.Lock()
+	delete(m.loggers, name)
+	m.mu.Unlock()
+}
+
+// recordCleanup 累计一次实际执行（非 dry-run）的清理结果。
+func (m *metricsRegistry) recordCleanup(report *CleanupReport) {
+	for _, f := range report.Deleted {
+		if stamp, ok := ParseLogFileName(f.Path); ok {
+			m.logger(stamp.Logger()).filesDeleted.Add(1)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if report.Emergency {
+		m.cleanup.EmergencyRuns++
+	} else {
+		m.cleanup.Runs++
+	}
+	m.cleanup.FilesDeleted += uint64(len(report.Deleted))
+	m.cleanup.BytesReclaimed += uint64(report.BytesReclaimed)
+	m.cleanup.Failures += uint64(len(report.Failures))
+	m.cleanup.LastRun = report.StartedAt
+}
+
+// snapshot 返回当前指标的副本。
+func (m *metricsRegistry) snapshot() Stats {
+	m.mu.Lock()
+	loggers := make(map[string]*loggerMetrics, len(m.loggers))
+	for name, lm := range m.loggers {
+		loggers[name] = lm
+	}
+	stats := Stats{Loggers: make(map[string]LoggerStats, len(loggers)), Cleanup: m.cleanup, Evictions: m.evictions.Load()}
+	m.mu.Unlock()
+
+	for name, lm := range loggers {
+		stats.Loggers[name] = lm.snapshot()
+	}
+	return stats
+}
+
+// entry 统计一条日志的等级。
+func (lm *loggerMetrics) entry(lvl zapcore.Level) {
+	if i := int(lvl - zapcore.DebugLevel); i >= 0 && i < levelCount {
+		lm.entries[i].Add(1)
+	}
+}
+
+// sampled 作为采样回调记录被丢弃的条目。
+func (lm *loggerMetrics) sampled(_ zapcore.Entry, dec zapcore.SamplingDecision) {
+	if dec&zapcore.LogDropped != 0 {
+		lm.dropSampled.Add(1)
+	}
+}
+
+// rotated 记录一次文件切割。
+func (lm *loggerMetrics) rotated() {
+	lm.rotations.Add(1)
+}
+
+// drop 记录一条因降级缓冲区溢出而丢弃的日志。
+func (lm *loggerMetrics) drop() {
+	lm.dropped.Add(1)
+}
+
+// writeFailed 记录一次写文件失败（日志已由兜底策略接管）。
+func (lm *loggerMetrics) writeFailed() {
+	lm.writeErrors.Add(1)
+}
+
+// setWriters 替换当前使用的文件 writer。
+func (lm *loggerMetrics) setWriters(writers []fileSizer) {
+	lm.mu.Lock()
+	lm.writers = writers
+	lm.mu.Unlock()
+}
+
+// countWrites 包装 writer，统计写入字节与失败次数。
+func (lm *loggerMetrics) countWrites(ws zapcore.WriteSyncer) zapcore.WriteSyncer {
+	return &countingWriter{WriteSyncer: ws, metrics: lm}
+}
+
+func (lm *loggerMetrics) snapshot() LoggerStats {
+	s := LoggerStats{
+		Entries:      make(map[zapcore.Level]uint64, levelCount),
+		BytesWritten: lm.bytes.Load(),
+		WriteErrors:  lm.writeErrors.Load(),
+		Dropped:      lm.dropped.Load(),
+		Rotations:    lm.rotations.Load(),
+		FilesDeleted: lm.filesDeleted.Load(),
+		Sampled:      lm.dropSampled.Load(),
+	}
+	for i := range lm.entries {
+		if n := lm.entries[i].Load(); n > 0 {
+			s.Entries[zapcore.DebugLevel+zapcore.Level(i)] = n
+		}
+	}
+
+	lm.mu.Lock()
+	writers := lm.writers
+	lm.mu.Unlock()
+	for _, w := range writers {
+		s.FileSize += w.fileSize()
+	}
+	return s
+}
+
+// countingWriter 统计写入字节数与写入失败。
+type countingWriter struct {
+	zapcore.WriteSyncer
+	metrics *loggerMetrics
+}
+
+// Write 写入并计数；条目的丢失由 meteredCore 按条目统计。
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.WriteSyncer.Write(p)
+	w.metrics.bytes.Add(uint64(n))
+	if err != nil {
+		w.metrics.writeErrors.Add(1)
+	}
+	return n, err
+}
+
+// meteredCore 将条目写入全部启用的子 core，并按条目统计：
+// 一条日志写入多个文件时只计一次等级，任意文件写入失败也只计一次丢失。
+type meteredCore struct {
+	cores   []zapcore.Core
+	metrics *loggerMetrics
+}
+
+// newMeteredCore 组合子 core 并统计到 metrics。
+func newMeteredCore(metrics *loggerMetrics, cores ...zapcore.Core) zapcore.Core {
+	return &meteredCore{cores: cores, metrics: metrics}
+}
+
+// Enabled 任一子 core 启用该等级时返回 true。
+func (c *meteredCore) Enabled(lvl zapcore.Level) bool {
+	for _, core := range c.cores {
+		if core.Enabled(lvl) {
+			return true
+		}
+	}
+	return false
+}
+
+// With 为每个子 core 附加字段。
+func (c *meteredCore) With(fields []zapcore.Field) zapcore.Core {
+	cores := make([]zapcore.Core, len(c.cores))
+	for i, core := range c.cores {
+		cores[i] = core.With(fields)
+	}
+	return &meteredCore{cores: cores, metrics: c.metrics}
+}
+
+// Check 以整体加入 CheckedEntry，使 Write 能看到一条日志的全部输出。
+func (c *meteredCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write 写入启用该等级的子 core，返回第一个错误。
+func (c *meteredCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	c.metrics.entry(ent.Level)
+	var firstErr error
+	for _, core := range c.cores {
+		if !core.Enabled(ent.Level) {
+			continue
+		}
+		if err := core.Write(ent, fields); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		c.metrics.dropped.Add(1)
+	}
+	return firstErr
+}
+
+// Sync 刷新全部子 core，返回第一个错误。
+func (c *meteredCore) Sync() error {
+	var firstErr error
+	for _, core := range c.cores {
+		if err := core.Sync(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// WritePrometheus 以 Prometheus 文本格式输出指标。
+func (s Stats) WritePrometheus(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	names := make([]string, 0, len(s.Loggers))
+	for name := range s.Loggers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	metric := func(name, kind, help string, value func(LoggerStats) float64) {
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+		for _, logger := range names {
+			fmt.Fprintf(bw, "%s{logger=\"%s\"} %v\n", name, escapeLabel(logger), value(s.Loggers[logger]))
+		}
+	}
+
+	fmt.Fprint(bw, "# HELP zlog_entries_total Log entries written per logger and level.\n# TYPE zlog_entries_total counter\n")
+	for _, logger := range names {
+		entries := s.Loggers[logger].Entries
+		for lvl := zapcore.DebugLevel; lvl <= zapcore.FatalLevel; lvl++ {
+			if n, ok := entries[lvl]; ok {
+				fmt.Fprintf(bw, "zlog_entries_total{logger=\"%s\",level=\"%s\"} %d\n", escapeLabel(logger), lvl, n)
+			}
+		}
+	}
+	metric("zlog_bytes_written_total", "counter", "Bytes written to log files.", func(l LoggerStats) float64 { return float64(l.BytesWritten) })
+	metric("zlog_write_errors_total", "counter", "Failed writes to log files.", func(l LoggerStats) float64 { return float64(l.WriteErrors) })
+	metric("zlog_dropped_entries_total", "counter", "Log entries lost because they could not be written.", func(l LoggerStats) float64 { return float64(l.Dropped) })
+	metric("zlog_rotations_total", "counter", "Log file rotations.", func(l LoggerStats) float64 { return float64(l.Rotations) })
+	metric("zlog_cleanup_files_deleted_total", "counter", "Log files deleted by cleanup.", func(l LoggerStats) float64 { return float64(l.FilesDeleted) })
+	metric("zlog_sampled_entries_total", "counter", "Log entries dropped by sampling.", func(l LoggerStats) float64 { return float64(l.Sampled) })
+	metric("zlog_file_size_bytes", "gauge", "Size of the log files currently being written.", func(l LoggerStats) float64 { return float64(l.FileSize) })
+
+	fmt.Fprint(bw, "# HELP zlog_level_escalation_expiry_seconds Unix time at which a temporary level set by SetLevelFor reverts.\n# TYPE zlog_level_escalation_expiry_seconds gauge\n")
+	for _, e := range s.Escalations {
+		fmt.Fprintf(bw, "zlog_level_escalation_expiry_seconds{logger=\"%s\",level=\"%s\"} %d\n", escapeLabel(e.Logger), e.Level, e.Expires.Unix())
+	}
+	fmt.Fprintf(bw, "# HELP zlog_active_loggers Loggers currently holding open files.\n# TYPE zlog_active_loggers gauge\nzlog_active_loggers %d\n", s.ActiveLoggers)
+	fmt.Fprintf(bw, "# HELP zlog_logger_evictions_total Times a logger's files were closed by MaxLoggers or the idle timeout.\n# TYPE zlog_logger_evictions_total counter\nzlog_logger_evictions_total %d\n", s.Evictions)
+	fmt.Fprintf(bw, "# HELP zlog_cleanup_runs_total Cleanup runs.\n# TYPE zlog_cleanup_runs_total counter\nzlog_cleanup_runs_total %d\n", s.Cleanup.Runs)
+	fmt.Fprintf(bw, "# HELP zlog_cleanup_emergency_runs_total Emergency cleanups triggered by a full disk.\n# TYPE zlog_cleanup_emergency_runs_total counter\nzlog_cleanup_emergency_runs_total %d\n", s.Cleanup.EmergencyRuns)
+	fmt.Fprintf(bw, "# HELP zlog_cleanup_bytes_reclaimed_total Bytes reclaimed by cleanup.\n# TYPE zlog_cleanup_bytes_reclaimed_total counter\nzlog_cleanup_bytes_reclaimed_total %d\n", s.Cleanup.BytesReclaimed)
+	fmt.Fprintf(bw, "# HELP zlog_cleanup_failures_total Files cleanup failed to archive or delete.\n# TYPE zlog_cleanup_failures_total counter\nzlog_cleanup_failures_total %d\n", s.Cleanup.Failures)
+	return bw.Flush()
+}
+
+// escapeLabel 按 Prometheus 文本格式转义标签值。
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+// Stats 返回当前实例的日志管道指标快照。
+func (m *Manager) Stats() Stats {
+	stats := m.registry.metrics.snapshot()
+	stats.ActiveLoggers = m.registry.activeCount()
+	stats.Escalations = m.LevelEscalations()
+	return stats
+}
+
+// MetricsHandler 返回以 Prometheus 文本格式输出指标的 http.Handler，可挂载到本地管理端口。
+func (m *Manager) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = m.Stats().WritePrometheus(w)
+	})
+}
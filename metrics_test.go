@@ -0,0 +1,96 @@
+package zlog
+
+import (
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// TestManagerStats 测试 logger 指标与 Prometheus 输出
+func TestManagerStats(t *testing.T) {
+	mgr, tmpDir := newTestManager(t, WithMaxAge(24))
+	logger := mgr.Logger("api")
+	for i := 0; i < 3; i++ {
+		logger.Info("hello")
+	}
+	logger.Error("boom")
+	logger.Debug("低于当前等级，不计数")
+
+	stats := mgr.Stats().Loggers["api"]
+	if stats.Entries[zapcore.InfoLevel] != 3 || stats.Entries[zapcore.ErrorLevel] != 1 || stats.Entries[zapcore.DebugLevel] != 0 {
+		t.Fatalf("unexpected entries: %v", stats.Entries)
+	}
+	if stats.BytesWritten == 0 || stats.FileSize == 0 {
+		t.Fatalf("expected bytes and file size, got %+v", stats)
+	}
+
+	t.Log("强制切割后计数")
+	lm := mgr.registry.metrics.logger("api")
+	if err := lm.writers[0].(*resilientWriter).current.(*rotateWriter).Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for mgr.Stats().Loggers["api"].Rotations == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected rotation to be counted")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Log("清理计数")
+	if err := os.WriteFile(filepath.Join(tmpDir, "api_info2020-01-01.log"), []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mgr.CleanupLogs()
+	stats = mgr.Stats().Loggers["api"]
+	if cleanup := mgr.Stats().Cleanup; cleanup.Runs != 1 || cleanup.FilesDeleted != 1 || stats.FilesDeleted != 1 {
+		t.Fatalf("unexpected cleanup stats: %+v / %+v", cleanup, stats)
+	}
+
+	rec := httptest.NewRecorder()
+	mgr.MetricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		`zlog_entries_total{logger="api",level="info"} 3`,
+		`zlog_entries_total{logger="api",level="error"} 1`,
+		`zlog_rotations_total{logger="api"} 1`,
+		`zlog_cleanup_runs_total 1`,
+		"# TYPE zlog_file_size_bytes gauge",
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("metrics output missing %q:\n%s", want, body)
+		}
+	}
+}
+
+type failingSyncer struct{}
+
+func (failingSyncer) Write([]byte) (int, error) { return 0, errors.New("disk gone") }
+func (failingSyncer) Sync() error               { return nil }
+
+// TestCountingWriterErrors 测试写入失败计数：写入两个文件都失败时记两次写入失败，但只丢失一条日志
+func TestCountingWriterErrors(t *testing.T) {
+	lm := &loggerMetrics{}
+	encoder := zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "message"})
+	core := newMeteredCore(lm,
+		zapcore.NewCore(encoder, lm.countWrites(failingSyncer{}), zapcore.InfoLevel),
+		zapcore.NewCore(encoder, lm.countWrites(failingSyncer{}), zapcore.ErrorLevel),
+	)
+	ce := core.Check(zapcore.Entry{Level: zapcore.ErrorLevel, Message: "x"}, nil)
+	if ce == nil {
+		t.Fatal("expected the entry to be enabled")
+	}
+	ce.Write()
+	if s := lm.snapshot(); s.WriteErrors != 2 || s.Dropped != 1 || s.BytesWritten != 0 || s.Entries[zapcore.ErrorLevel] != 1 {
+		t.Fatalf("unexpected stats: %+v", s)
+	}
+	if got := escapeLabel("a\"b\\c"); got != `a\"b\\c` {
+		t.Fatalf("escapeLabel = %q", got)
+	}
+}
@@ -0,0 +1,160 @@
+package zlog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+// DefaultMaxLoggerNameLength 是 logger 名称的默认最大字节数，为文件名中的后缀与日期留出余量。
+const DefaultMaxLoggerNameLength = 128
+
+// ErrInvalidLoggerName 表示 logger 名称不能安全地用作文件名。
+var ErrInvalidLoggerName = errors.New("zlog: invalid logger name")
+
+// validLoggerNameRune 判断字符是否允许出现在 logger 名称中：字母、数字、'_'、'-'、'.'。
+func validLoggerNameRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.'
+}
+
+// maxLoggerNameLength 返回生效的名称长度上限。
+func (cfg Config) maxLoggerNameLength() int {
+	if cfg.MaxLoggerNameLength > 0 {
+		return cfg.MaxLoggerNameLength
+	}
+	return DefaultMaxLoggerNameLength
+}
+
+// ValidateLoggerName 检查名称能否作为日志文件前缀：非空、不超过 maxLen 字节、
+// 只包含字母、数字、'_'、'-'、'.'，且不以 '.' 开头（排除 "."、".." 与隐藏文件）。maxLen<=0 时使用默认上限。
+func ValidateLoggerName(name string, maxLen int) error {
+	if maxLen <= 0 {
+		maxLen = DefaultMaxLoggerNameLength
+	}
+	switch {
+	case name == "":
+		return fmt.Errorf("%w: empty", ErrInvalidLoggerName)
+	case len(name) > maxLen:
+		return fmt.Errorf("%w: %q is longer than %d bytes", ErrInvalidLoggerName, name, maxLen)
+	case !utf8.ValidString(name):
+		return fmt.Errorf("%w: %q is not valid UTF-8", ErrInvalidLoggerName, name)
+	case strings.HasPrefix(name, "."):
+		return fmt.Errorf("%w: %q starts with '.'", ErrInvalidLoggerName, name)
+	}
+	for _, r := range name {
+		if !validLoggerNameRune(r) {
+			return fmt.Errorf("%w: %q contains %q", ErrInvalidLoggerName, name, r)
+		}
+	}
+	return nil
+}
+
+// SanitizeLoggerName 将不允许的字符（包括路径分隔符与 NUL）替换为 '_'，替换开头的 '.'，
+// 并截断到 maxLen 字节，结果总能通过 ValidateLoggerName。maxLen<=0 时使用默认上限。
+func SanitizeLoggerName(name string, maxLen int) string {
+	if maxLen <= 0 {
+		maxLen = DefaultMaxLoggerNameLength
+	}
+	var b strings.Builder
+	for i, r := range strings.ToValidUTF8(name, "_") {
+		if !validLoggerNameRune(r) || (i == 0 && r == '.') {
+			r = '_'
+		}
+		if b.Len()+utf8.RuneLen(r) > maxLen {
+			break
+		}
+		b.WriteRune(r)
+	}
+	if b.Len() == 0 {
+		return "_"
+	}
+	return b.String()
+}
+
+// resolveLoggerName 校验名称；开启 LoggerNameMapping 时先替换不安全字符。
+func (cfg Config) resolveLoggerName(name string) (string, error) {
+	maxLen := cfg.maxLoggerNameLength()
+	if cfg.LoggerNameMapping {
+		name = SanitizeLoggerName(name, maxLen)
+	}
+	if err := ValidateLoggerName(name, maxLen); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// maxRenameNotices 是每个实例提示改名的次数上限，避免高基数的非法名称让提示与内存无限增长。
+const maxRenameNotices = 1024
+
+// noteRename 在 Logger/F 替换名称中的不安全字符时向 stderr 提示一次；不同的名称落到同一个文件前缀时
+// （如 "a/b" 与 "a_b"），每个冲突的名称再提示一次，它们的日志会写入同一组文件。
+func (m *Manager) noteRename(name, resolved string) {
+	m.renameMu.Lock()
+	defer m.renameMu.Unlock()
+
+	noted := m.renamed[resolved]
+	if m.renameNotes >= maxRenameNotices || containsName(noted, name) {
+		return
+	}
+	switch {
+	case len(noted) > 0:
+		fmt.Fprintf(os.Stderr, "zlog: logger name %q collides with %q, both write to %s_*.log\n", name, noted[0], resolved)
+	case name == resolved:
+		// 合法名称，只在之后有名称被替换成它时提示冲突
+		return
+	default:
+		if _, exists := m.registry.get(resolved); exists {
+			fmt.Fprintf(os.Stderr, "zlog: logger name %q collides with %q, both write to %s_*.log\n", name, resolved, resolved)
+		} else {
+			fmt.Fprintf(os.Stderr, "zlog: logger name %q is not a valid file name, writing to %s_*.log instead\n", name, resolved)
+		}
+	}
+	if m.renamed == nil {
+		m.renamed = make(map[string][]string)
+	}
+	m.renamed[resolved] = append(noted, name)
+	m.renameNotes++
+}
+
+// containsName 判断 names 中是否包含 name。
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// LoggerE 与 Logger 相同，但名称无法安全用作文件名时返回错误，而不是替换不安全字符。
+func (m *Manager) LoggerE(fileNameArr ...string) (*zap.SugaredLogger, error) {
+	pair, err := m.loggerE(fileNameArr...)
+	if err != nil {
+		return nil, err
+	}
+	return pair.sugar, nil
+}
+
+// loggerE 解析名称与调用栈深度参数，名称非法时返回错误。
+func (m *Manager) loggerE(fileNameArr ...string) (*loggerPair, error) {
+	cfg := m.getConfig()
+	name := cfg.DefaultLoggerName
+	if len(fileNameArr) > 0 && fileNameArr[0] != "" {
+		resolved, err := cfg.resolveLoggerName(fileNameArr[0])
+		if err != nil {
+			return nil, err
+		}
+		name = resolved
+	}
+	return m.registry.getOrCreate(name, callerSkip(fileNameArr)), nil
+}
+
+// LoggerE 返回全局实例中指定名称的 SugaredLogger，名称非法时返回错误。
+func LoggerE(fileNameArr ...string) (*zap.SugaredLogger, error) {
+	return getDefaultManager().LoggerE(fileNameArr...)
+}
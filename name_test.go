@@ -0,0 +1,151 @@
+package zlog
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestValidateLoggerName 测试名称校验规则
+func TestValidateLoggerName(t *testing.T) {
+	valid := []string{"api", "order_api", "tenant-42", "api.db", "订单"}
+	for _, name := range valid {
+		if err := ValidateLoggerName(name, 0); err != nil {
+			t.Fatalf("%q should be valid: %v", name, err)
+		}
+	}
+
+	invalid := []string{"", ".", "..", ".hidden", "../x", "a/b", `a\b`, "a\x00b", "a b", "a:b", "\xff", strings.Repeat("a", DefaultMaxLoggerNameLength+1)}
+	for _, name := range invalid {
+		if err := ValidateLoggerName(name, 0); !errors.Is(err, ErrInvalidLoggerName) {
+			t.Fatalf("%q should be rejected, got %v", name, err)
+		}
+	}
+	if err := ValidateLoggerName("abcdef", 5); err == nil {
+		t.Fatal("expected custom max length to apply")
+	}
+}
+
+// TestSanitizeLoggerName 测试替换结果总能通过校验
+func TestSanitizeLoggerName(t *testing.T) {
+	cases := map[string]string{
+		"../../etc/cron.d/x": "_._.._etc_cron.d_x",
+		"a\x00b":             "a_b",
+		"..":                 "_.",
+		"":                   "_",
+		"api":                "api",
+	}
+	for in, want := range cases {
+		got := SanitizeLoggerName(in, 0)
+		if got != want {
+			t.Fatalf("SanitizeLoggerName(%q) = %q, want %q", in, got, want)
+		}
+		if err := ValidateLoggerName(got, 0); err != nil {
+			t.Fatalf("sanitized %q still invalid: %v", got, err)
+		}
+	}
+	if got := SanitizeLoggerName("订单订单", 7); got != "订单" {
+		t.Fatalf("truncation must not split runes, got %q", got)
+	}
+}
+
+// TestLoggerNameTraversal 测试路径穿越名称不会写到日志目录之外
+func TestLoggerNameTraversal(t *testing.T) {
+	root := t.TempDir()
+	logsDir := filepath.Join(root, "logs")
+	origDir := logDir()
+	t.Cleanup(func() { setLogDir(origDir) })
+
+	mgr := NewManager(WithLogDir(logsDir), WithAutoCleanup(false))
+	attempts := []string{"../escaped", "../../etc/cron.d/x", "/abs/path", "nul\x00byte", `..\win`}
+	for _, name := range attempts {
+		if _, err := mgr.LoggerE(name); !errors.Is(err, ErrInvalidLoggerName) {
+			t.Fatalf("LoggerE(%q) should fail, got %v", name, err)
+		}
+		mgr.Logger(name).Info("traversal attempt")
+	}
+	_ = mgr.Close()
+
+	t.Log("日志目录之外不应出现任何文件")
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "logs" {
+		t.Fatalf("files written outside the log dir: %v", entries)
+	}
+	err = filepath.Walk(logsDir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.IsDir() && path != logsDir {
+			t.Fatalf("unexpected sub directory %s", path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content := readTodayLog(t, logsDir, "_._escaped_info"); !strings.Contains(content, "traversal attempt") {
+		t.Fatalf("sanitized logger did not write: %q", content)
+	}
+}
+
+// TestLoggerNameMapping 测试开启映射后 LoggerE 替换不安全字符
+func TestLoggerNameMapping(t *testing.T) {
+	mgr, tmpDir := newTestManager(t, WithLoggerNameMapping(true), WithMaxLoggerNameLength(12))
+	logger, err := mgr.LoggerE("tenant/acme corp")
+	if err != nil {
+		t.Fatalf("LoggerE with mapping: %v", err)
+	}
+	if logger != mgr.Logger("tenant_acme_") {
+		t.Fatal("mapped name should share the cached logger")
+	}
+	logger.Info("mapped")
+	if content := readTodayLog(t, tmpDir, "tenant_acme__info"); !strings.Contains(content, "mapped") {
+		t.Fatalf("unexpected content: %q", content)
+	}
+}
+
+// TestLoggerRenameNotice 测试名称被替换时提示一次，替换后与其他名称冲突时提示冲突
+func TestLoggerRenameNotice(t *testing.T) {
+	mgr, tmpDir := newTestManager(t)
+	stderr := captureStderr(t, func() {
+		mgr.Logger("a/b").Info("from a/b")
+		mgr.Logger("a/b").Info("again")
+		mgr.Logger("a_b").Info("from a_b")
+		mgr.Logger("a_b").Info("again")
+		mgr.Logger("c_d").Info("valid first")
+		mgr.Logger("c d").Info("renamed later")
+	})
+
+	if strings.Count(stderr, `"a/b" is not a valid file name, writing to a_b_*.log`) != 1 {
+		t.Fatalf("expected one rename notice for a/b: %q", stderr)
+	}
+	if strings.Count(stderr, `"a_b" collides with "a/b"`) != 1 || strings.Count(stderr, `"c d" collides with "c_d"`) != 1 {
+		t.Fatalf("expected one collision notice per name: %q", stderr)
+	}
+	if content := readTodayLog(t, tmpDir, "a_b_info"); !strings.Contains(content, "from a/b") || !strings.Contains(content, "from a_b") {
+		t.Fatalf("colliding names should share the file: %q", content)
+	}
+}
+
+// captureStderr 返回 fn 执行期间写入 os.Stderr 的内容。
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = orig }()
+
+	fn()
+	_ = w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
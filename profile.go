@@ -0,0 +1,95 @@
+package zlog
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// profiles 保存 RegisterProfile 注册的环境配置。
+var (
+	profilesMu sync.RWMutex
+	profiles   = make(map[Env][]LogOption)
+)
+
+// RegisterProfile 注册名为 env 的环境配置，例如 "staging"、"loadtest"、"canary"。
+// 通过 SetLog/SetEnv 或 ZLOG_ENV 选择该环境时先应用这些选项，再应用调用方传入的选项；
+// 也可以覆盖内置环境（如 ENV_PRO）。未通过 WithLevel 指定等级的 profile 使用 Info。
+// 重复注册同名 env 会替换之前的选项，已生效的实例在下一次 SetLog 时使用新选项。
+func RegisterProfile(env Env, options ...LogOption) {
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+	profiles[env] = append([]LogOption(nil), options...)
+}
+
+// profileOptions 返回 env 注册的选项。
+func profileOptions(env Env) []LogOption {
+	profilesMu.RLock()
+	defer profilesMu.RUnlock()
+	return profiles[env]
+}
+
+// knownEnv 判断 env 是内置环境或已注册的 profile。
+func knownEnv(env Env) bool {
+	if _, ok := envLevelMap[env]; ok {
+		return true
+	}
+	profilesMu.RLock()
+	defer profilesMu.RUnlock()
+	_, ok := profiles[env]
+	return ok
+}
+
+// defaultSamplingTick 是采样的默认统计周期。
+const defaultSamplingTick = time.Second
+
+// Sampling 描述日志采样：每个 Tick 内同一等级、同一消息的日志先记录 Initial 条，之后每 Thereafter 条记录一条。
+type Sampling struct {
+	Tick       time.Duration // 统计周期，0 表示 1 秒
+	Initial    int
+	Thereafter int
+}
+
+// WithSampling 开启日志采样以限制高频重复日志的量，initial 为 0 时关闭，thereafter 为 0 时丢弃周期内其余条目。
+// 被丢弃的条数计入 Sampled 指标。
+func WithSampling(initial, thereafter int, tick time.Duration) LogOption {
+	return func(cfg *Config) {
+		if initial == 0 {
+			cfg.Sampling = nil
+			return
+		}
+		cfg.Sampling = &Sampling{Tick: tick, Initial: initial, Thereafter: thereafter}
+	}
+}
+
+// String 返回 "initial/thereafter per tick" 形式的描述，未开启时为 "off"。
+func (s *Sampling) String() string {
+	if s == nil {
+		return "off"
+	}
+	tick := s.Tick
+	if tick <= 0 {
+		tick = defaultSamplingTick
+	}
+	return fmt.Sprintf("%d/%d per %s", s.Initial, s.Thereafter, tick)
+}
+
+// sample 按配置为 core 加上采样，丢弃的条目计入 metrics。
+func (s *Sampling) sample(core zapcore.Core, metrics *loggerMetrics) zapcore.Core {
+	if s == nil {
+		return core
+	}
+	tick := s.Tick
+	if tick <= 0 {
+		tick = defaultSamplingTick
+	}
+	thereafter := s.Thereafter
+	if thereafter <= 0 {
+		// zap 不接受 0，用足够大的值表示周期内其余条目全部丢弃
+		thereafter = math.MaxInt32
+	}
+	return zapcore.NewSamplerWithOptions(core, tick, s.Initial, thereafter, zapcore.SamplerHook(metrics.sampled))
+}
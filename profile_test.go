@@ -0,0 +1,118 @@
+package zlog
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// registerTestProfile 注册测试用 profile，并在测试结束时移除
+func registerTestProfile(t *testing.T, env Env, options ...LogOption) {
+	RegisterProfile(env, options...)
+	t.Cleanup(func() {
+		profilesMu.Lock()
+		delete(profiles, env)
+		profilesMu.Unlock()
+	})
+}
+
+// TestRegisterProfile 测试通过 SetLog、SetEnv 与 ZLOG_ENV 选择自定义 profile
+func TestRegisterProfile(t *testing.T) {
+	registerTestProfile(t, "staging", WithLevel(zapcore.WarnLevel), WithMaxAge(48), WithDate(DATE_MSEC))
+
+	mgr, _ := newTestManager(t)
+	mgr.SetLog("staging")
+	cfg := mgr.getConfig()
+	if cfg.Level != zapcore.WarnLevel || cfg.WithMaxAge != 48 || cfg.formDate != DATE_MSEC {
+		t.Fatalf("profile not applied: level=%s max_age=%d format=%s", cfg.Level, cfg.WithMaxAge, cfg.formDate)
+	}
+
+	t.Log("调用方选项优先于 profile")
+	mgr.SetLog("staging", WithLevel(zapcore.DebugLevel))
+	if cfg := mgr.getConfig(); cfg.Level != zapcore.DebugLevel || cfg.WithMaxAge != 48 {
+		t.Fatalf("options should win over the profile: level=%s max_age=%d", cfg.Level, cfg.WithMaxAge)
+	}
+
+	t.Log("切回内置环境时等级恢复为环境默认值")
+	mgr.SetLog(ENV_PRO)
+	if level := mgr.getConfig().Level; level != zapcore.InfoLevel {
+		t.Fatalf("expected info after switching back, got %s", level)
+	}
+
+	t.Log("校验接受已注册的 profile")
+	if err := mgr.SetLogE("staging"); err != nil {
+		t.Fatalf("SetLogE: %v", err)
+	}
+	if err := mgr.SetLogE("unregistered"); err == nil {
+		t.Fatal("expected an error for an unregistered env")
+	}
+
+	t.Log("通过 ZLOG_ENV 选择 profile")
+	t.Setenv(envVarEnv, "staging")
+	envMgr, _ := newTestManager(t)
+	if cfg := envMgr.getConfig(); cfg.Env != "staging" || cfg.Level != zapcore.WarnLevel {
+		t.Fatalf("ZLOG_ENV did not select the profile: env=%s level=%s", cfg.Env, cfg.Level)
+	}
+}
+
+// TestProfileSwitchBack 测试切换到其他环境时 profile 的选项不会残留，NewManager 的选项仍然保留
+func TestProfileSwitchBack(t *testing.T) {
+	registerTestProfile(t, "loadtest", WithConsoleOnly(true), WithSampling(1, 0, time.Second), WithMaxAge(2))
+
+	mgr, tmpDir := newTestManager(t, WithMaxFiles(7))
+	mgr.SetLog("loadtest")
+	if cfg := mgr.getConfig(); !cfg.ConsoleOnly || cfg.Sampling == nil || cfg.WithMaxAge != 2 {
+		t.Fatalf("profile not applied: %+v", cfg)
+	}
+
+	mgr.SetLog(ENV_PRO)
+	cfg := mgr.getConfig()
+	if cfg.ConsoleOnly || cfg.Sampling != nil || cfg.WithMaxAge != 10*24 {
+		t.Fatalf("loadtest options leaked into pro: console_only=%v sampling=%v max_age=%d", cfg.ConsoleOnly, cfg.Sampling, cfg.WithMaxAge)
+	}
+	if cfg.LogDir != tmpDir || cfg.MaxFiles != 7 || cfg.Level != zapcore.InfoLevel {
+		t.Fatalf("NewManager options lost after switching: log_dir=%s max_files=%d level=%s", cfg.LogDir, cfg.MaxFiles, cfg.Level)
+	}
+
+	t.Log("再次切回 profile 时重新应用")
+	if err := mgr.SetLogE("loadtest", WithMaxAge(5)); err != nil {
+		t.Fatal(err)
+	}
+	if cfg := mgr.getConfig(); !cfg.ConsoleOnly || cfg.WithMaxAge != 5 || cfg.MaxFiles != 7 {
+		t.Fatalf("unexpected config after switching back: %+v", cfg)
+	}
+}
+
+// TestSampling 测试采样丢弃重复日志并计入指标
+func TestSampling(t *testing.T) {
+	registerTestProfile(t, "loadtest", WithSampling(2, 0, time.Minute))
+
+	mgr, tmpDir := newTestManager(t)
+	mgr.SetLog("loadtest")
+	logger := mgr.Logger("api")
+	for i := 0; i < 10; i++ {
+		logger.Info("hot path")
+	}
+	logger.Info("other")
+	_ = mgr.Sync("api")
+
+	content := readTodayLog(t, tmpDir, "api_info")
+	if n := strings.Count(content, "hot path"); n != 2 {
+		t.Fatalf("expected 2 sampled entries, got %d: %q", n, content)
+	}
+	if !strings.Contains(content, "other") {
+		t.Fatalf("distinct message should not be sampled: %q", content)
+	}
+	stats := mgr.Stats().Loggers["api"]
+	if stats.Sampled != 8 || stats.Entries[zapcore.InfoLevel] != 3 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	t.Log("WithSampling(0, ...) 关闭采样")
+	mgr.SetLog("loadtest", WithSampling(0, 0, 0))
+	if s := mgr.getConfig().Sampling; s != nil {
+		t.Fatalf("expected sampling disabled, got %v", s)
+	}
+}
@@ -0,0 +1,310 @@
+// Package query 读取 zlog 写入的 JSON 日志文件，支持跨日期、切割序号（.N）与 .gz 压缩文件迭代，
+// 并按时间范围、等级、logger、调用方与字段值过滤，适用于测试与运维工具。
+package query
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Xuzan9396/zlog"
+	"go.uber.org/zap/zapcore"
+)
+
+// ErrStop 可由 Each 的回调返回，提前结束迭代且不视为错误。
+var ErrStop = errors.New("query: stop iteration")
+
+// 与 zlog 编码器保持一致的字段名。
+const (
+	keyTime    = "time"
+	keyLevel   = "level"
+	keyLogger  = "f"
+	keyCaller  = "line"
+	keyMessage = "message"
+	keyStack   = "stacktrace"
+)
+
+// timeLayouts 在 Query.TimeLayout 之后按顺序尝试解析 time 字段。
+var timeLayouts = []string{string(zlog.DATE_MSEC), string(zlog.DATE_SEC), time.RFC3339Nano}
+
+// File 描述目录下一个可读取的日志文件。
+type File struct {
+	zlog.LogFile
+	Path string // 完整路径
+}
+
+// Entry 是解码后的单条日志。
+type Entry struct {
+	Time       time.Time              // 无法按已知格式解析时为零值
+	Level      zapcore.Level          // level 字段
+	Logger     string                 // 条目中的 f 字段，缺省时由文件名推导（共享错误文件除外）
+	Caller     string                 // line 字段，例如 order/service.go:42
+	Message    string                 // message 字段
+	Stacktrace string                 // stacktrace 字段
+	Fields     map[string]interface{} // 其余业务字段，数字以 json.Number 保存
+	File       string                 // 来源文件路径
+	Line       int                    // 在来源文件中的行号（从 1 开始）
+	Raw        []byte                 // 原始 JSON 行
+}
+
+// Query 描述一次查询，零值字段表示不过滤。
+type Query struct {
+	Dir        string            // 日志目录
+	Logger     string            // logger 名称，例如 api 只匹配 api_info/api_error 等文件及共享错误文件中 f 为 api 的条目
+	Kinds      []string          // 文件种类，例如 info、error；为空表示全部
+	Since      time.Time         // 起始时间（包含）
+	Until      time.Time         // 截止时间（包含）
+	Levels     []zapcore.Level   // 允许的等级，可用 AtLeast 生成
+	Caller     string            // 调用方子串匹配，例如 order.go 或 order.go:42
+	Message    string            // 消息子串匹配
+	Fields     map[string]string // 字段等值匹配，值统一按字符串比较
+	Location   *time.Location    // 解析 time 字段使用的时区，默认 zlog.Location()（ZLOG_TZ）
+	TimeLayout string            // time 字段格式，默认 zlog.DefaultDate()（ZLOG_FORMAT）；使用 WithDate 自定义格式时需与之一致
+	ErrorName  string            // 共享错误文件前缀，默认 zlog.DefaultErrorName()；其条目只按 f 字段归属 logger
+}
+
+// AtLeast 返回不低于 min 的全部等级，便于构造 Query.Levels。
+func AtLeast(min zapcore.Level) []zapcore.Level {
+	var levels []zapcore.Level
+	for lvl := min; lvl <= zapcore.FatalLevel; lvl++ {
+		levels = append(levels, lvl)
+	}
+	return levels
+}
+
+// Files 返回匹配 Logger/Kinds 的日志文件，按时间戳与切割序号升序排列。
+// 软链接（如 api_info.log）与无法解析的文件会被跳过。
+func (q Query) Files() ([]File, error) {
+	entries, err := os.ReadDir(q.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	errorName := q.errorName()
+	var files []File
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		lf, ok := zlog.ParseLogFileName(entry.Name())
+		if !ok {
+			continue
+		}
+		if q.Logger != "" && lf.Logger() != q.Logger && lf.Prefix != errorName {
+			continue
+		}
+		if len(q.Kinds) > 0 && !containsString(q.Kinds, lf.Kind()) {
+			continue
+		}
+		// 文件起始时间晚于截止时间时整体跳过
+		if !q.Until.IsZero() && lf.Time.After(q.Until) {
+			continue
+		}
+		files = append(files, File{LogFile: lf, Path: filepath.Join(q.Dir, entry.Name())})
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		if !files[i].Time.Equal(files[j].Time) {
+			return files[i].Time.Before(files[j].Time)
+		}
+		if files[i].Prefix != files[j].Prefix {
+			return files[i].Prefix < files[j].Prefix
+		}
+		return files[i].Generation < files[j].Generation
+	})
+	return files, nil
+}
+
+// Each 依次读取匹配的文件并对满足条件的条目调用 fn，fn 返回 ErrStop 时提前结束。
+// 非 JSON 行（例如 SetZapOut 写入的标准库日志）会被忽略。
+func (q Query) Each(fn func(Entry) error) error {
+	files, err := q.Files()
+	if err != nil {
+		return err
+	}
+	if q.TimeLayout == "" {
+		q.TimeLayout = string(zlog.DefaultDate())
+	}
+	for _, f := range files {
+		if err := q.scanFile(f, fn); err != nil {
+			if errors.Is(err, ErrStop) {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// All 返回全部满足条件的条目。
+func (q Query) All() ([]Entry, error) {
+	var result []Entry
+	err := q.Each(func(e Entry) error {
+		result = append(result, e)
+		return nil
+	})
+	return result, err
+}
+
+// Match 判断单条日志是否满足查询条件（不检查 Logger/Kinds 的文件级过滤）。
+// time 字段无法解析（Time 为零值）的条目不参与 Since/Until 过滤，以免被静默丢弃。
+func (q Query) Match(e Entry) bool {
+	if !q.Since.IsZero() && !e.Time.IsZero() && e.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && e.Time.After(q.Until) {
+		return false
+	}
+	if len(q.Levels) > 0 && !containsLevel(q.Levels, e.Level) {
+		return false
+	}
+	if q.Caller != "" && !strings.Contains(e.Caller, q.Caller) {
+		return false
+	}
+	if q.Message != "" && !strings.Contains(e.Message, q.Message) {
+		return false
+	}
+	for key, want := range q.Fields {
+		value, ok := e.Fields[key]
+		if !ok || fmt.Sprint(value) != want {
+			return false
+		}
+	}
+	return true
+}
+
+// scanFile 逐行解码单个文件，压缩文件透明解压。
+func (q Query) scanFile(f File, fn func(Entry) error) error {
+	fh, err := os.Open(f.Path)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	var r io.Reader = fh
+	if f.Compressed {
+		gz, err := gzip.NewReader(fh)
+		if err != nil {
+			return fmt.Errorf("query: open %s: %w", f.Path, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	// 共享错误文件汇集所有 logger 的错误，文件名不代表 logger，条目只按 f 字段归属
+	shared := f.Prefix == q.errorName()
+	reader := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		raw, readErr := reader.ReadBytes('\n')
+		raw = bytes.TrimRight(raw, "\r\n")
+		if len(raw) > 0 {
+			if entry, ok := q.decode(raw); ok {
+				entry.File = f.Path
+				entry.Line = line
+				if entry.Logger == "" && !shared {
+					entry.Logger = f.Logger()
+				}
+				if (!shared || q.Logger == "" || entry.Logger == q.Logger) && q.Match(entry) {
+					if err := fn(entry); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
+
+// errorName 返回共享错误文件前缀。
+func (q Query) errorName() string {
+	if q.ErrorName != "" {
+		return q.ErrorName
+	}
+	return zlog.DefaultErrorName()
+}
+
+// Decode 将一行 zlog JSON 日志解码为 Entry，无法解析时返回错误。
+func Decode(line []byte) (Entry, error) {
+	entry, ok := Query{}.decode(line)
+	if !ok {
+		return Entry{}, errors.New("query: not a zlog json entry")
+	}
+	return entry, nil
+}
+
+func (q Query) decode(raw []byte) (Entry, bool) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var fields map[string]interface{}
+	if err := dec.Decode(&fields); err != nil || fields == nil {
+		return Entry{}, false
+	}
+
+	loc := q.Location
+	if loc == nil {
+		loc = zlog.Location()
+	}
+
+	entry := Entry{Raw: append([]byte(nil), raw...)}
+	if s, ok := fields[keyTime].(string); ok {
+		layout := q.TimeLayout
+		if layout == "" {
+			layout = string(zlog.DefaultDate())
+		}
+		for _, layout := range append([]string{layout}, timeLayouts...) {
+			if ts, err := time.ParseInLocation(layout, s, loc); err == nil {
+				entry.Time = ts
+				break
+			}
+		}
+	}
+	if s, ok := fields[keyLevel].(string); ok {
+		if err := entry.Level.UnmarshalText([]byte(s)); err != nil {
+			return Entry{}, false
+		}
+	} else {
+		return Entry{}, false
+	}
+	entry.Logger, _ = fields[keyLogger].(string)
+	entry.Caller, _ = fields[keyCaller].(string)
+	entry.Message, _ = fields[keyMessage].(string)
+	entry.Stacktrace, _ = fields[keyStack].(string)
+
+	for _, key := range []string{keyTime, keyLevel, keyLogger, keyCaller, keyMessage, keyStack} {
+		delete(fields, key)
+	}
+	entry.Fields = fields
+	return entry, true
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsLevel(list []zapcore.Level, lvl zapcore.Level) bool {
+	for _, v := range list {
+		if v == lvl {
+			return true
+		}
+	}
+	return false
+}
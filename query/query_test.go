@@ -0,0 +1,242 @@
+package query
+
+import (
+	"compress/gzip"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Xuzan9396/zlog"
+	"go.uber.org/zap/zapcore"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeGzip(t *testing.T, path, content string) {
+	t.Helper()
+	fh, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(fh)
+	if _, err := gz.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := fh.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newFixture 构造包含多日期、切割序号与压缩文件的日志目录。
+func newFixture(t *testing.T) string {
+	dir := t.TempDir()
+	writeGzip(t, filepath.Join(dir, "api_info2025-01-14.log.gz"),
+		`{"level":"info","time":"2025-01-14 23:59:00","line":"api/handler.go:10","message":"old","user":"u1"}`+"\n")
+	writeFile(t, filepath.Join(dir, "api_info2025-01-15.log"),
+		`{"level":"info","time":"2025-01-15 08:00:00","line":"api/handler.go:12","message":"login","user":"u1","cost":12}`+"\n"+
+			`not json`+"\n"+
+			`{"level":"warn","time":"2025-01-15 09:00:00.250","line":"api/limit.go:30","message":"slow","user":"u2"}`+"\n")
+	writeFile(t, filepath.Join(dir, "api_info2025-01-15.log.1"),
+		`{"level":"error","time":"2025-01-15 10:00:00","line":"api/handler.go:40","message":"boom","user":"u1"}`+"\n")
+	writeFile(t, filepath.Join(dir, "order_api_info2025-01-15.log"),
+		`{"level":"info","time":"2025-01-15 08:30:00","line":"order/api.go:5","message":"order"}`+"\n")
+	if err := os.Symlink("api_info2025-01-15.log", filepath.Join(dir, "api_info.log")); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestFilesOrderAndLoggerMatch(t *testing.T) {
+	dir := newFixture(t)
+
+	files, err := Query{Dir: dir, Logger: "api"}.Files()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"api_info2025-01-14.log.gz", "api_info2025-01-15.log", "api_info2025-01-15.log.1"}
+	if len(files) != len(want) {
+		t.Fatalf("expected %d files, got %+v", len(want), files)
+	}
+	for i, f := range files {
+		if f.Name != want[i] {
+			t.Fatalf("file %d: expected %s, got %s", i, want[i], f.Name)
+		}
+	}
+}
+
+func TestAllWithFilters(t *testing.T) {
+	dir := newFixture(t)
+
+	entries, err := Query{Dir: dir, Logger: "api"}.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("expected 4 entries, got %d", len(entries))
+	}
+	if entries[0].Message != "old" || entries[3].Message != "boom" {
+		t.Fatalf("unexpected order: %s ... %s", entries[0].Message, entries[3].Message)
+	}
+	if entries[1].Logger != "api" || entries[1].Caller != "api/handler.go:12" || entries[1].Line != 1 {
+		t.Fatalf("unexpected entry metadata: %+v", entries[1])
+	}
+	if got := entries[1].Fields["cost"]; got == nil || got.(interface{ String() string }).String() != "12" {
+		t.Fatalf("expected cost field 12, got %v", got)
+	}
+
+	loc := time.Local
+	cases := []struct {
+		name  string
+		query Query
+		want  []string
+	}{
+		{"level", Query{Levels: AtLeast(zapcore.WarnLevel)}, []string{"slow", "boom"}},
+		{"time", Query{Since: time.Date(2025, 1, 15, 8, 0, 0, 0, loc), Until: time.Date(2025, 1, 15, 9, 30, 0, 0, loc)}, []string{"login", "slow"}},
+		{"caller", Query{Caller: "handler.go"}, []string{"old", "login", "boom"}},
+		{"field", Query{Fields: map[string]string{"user": "u1", "cost": "12"}}, []string{"login"}},
+		{"message", Query{Message: "lo"}, []string{"login", "slow"}},
+	}
+	for _, tc := range cases {
+		tc.query.Dir = dir
+		tc.query.Logger = "api"
+		got, err := tc.query.All()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(tc.want) {
+			t.Fatalf("%s: expected %v, got %d entries", tc.name, tc.want, len(got))
+		}
+		for i, e := range got {
+			if e.Message != tc.want[i] {
+				t.Fatalf("%s: entry %d expected %s, got %s", tc.name, i, tc.want[i], e.Message)
+			}
+		}
+	}
+}
+
+func TestEachStop(t *testing.T) {
+	dir := newFixture(t)
+
+	count := 0
+	err := Query{Dir: dir}.Each(func(Entry) error {
+		count++
+		return ErrStop
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatalf("expected iteration to stop after first entry, got %d", count)
+	}
+}
+
+func TestQueryManagerOutput(t *testing.T) {
+	dir := t.TempDir()
+	mgr := zlog.NewManager(zlog.WithLogDir(dir), zlog.WithAutoCleanup(false), zlog.WithDate(zlog.DATE_MSEC))
+	mgr.Logger("billing").Infow("charged", "order_id", "A100", "amount", 42)
+	mgr.Logger("billing").Errorw("refund failed", "order_id", "A101")
+
+	entries, err := Query{Dir: dir, Logger: "billing", Kinds: []string{"info"}, Fields: map[string]string{"order_id": "A100"}}.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Message != "charged" || entries[0].Level != zapcore.InfoLevel {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+	if entries[0].Time.IsZero() {
+		t.Fatal("expected entry time to be parsed")
+	}
+}
+
+// TestSharedErrorFileAttribution 测试共享错误文件中的条目按 f 字段归属 logger，而不是按文件名
+func TestSharedErrorFileAttribution(t *testing.T) {
+	dir := t.TempDir()
+	mgr := zlog.NewManager(zlog.WithLogDir(dir), zlog.WithAutoCleanup(false))
+	t.Cleanup(func() { _ = mgr.Close() })
+	mgr.Logger("api").Error("api failed")
+	mgr.Logger("order").Error("order failed")
+
+	cases := []struct {
+		logger string
+		want   []string
+	}{
+		{"order", []string{"order failed"}},
+		{"api", []string{"api failed"}},
+		{"log", nil},
+		{"", []string{"api failed", "order failed"}},
+	}
+	for _, tc := range cases {
+		entries, err := Query{Dir: dir, Logger: tc.logger, Kinds: []string{"error"}}.All()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != len(tc.want) {
+			t.Fatalf("logger %q: expected %v, got %+v", tc.logger, tc.want, entries)
+		}
+		for i, e := range entries {
+			if e.Message != tc.want[i] || e.Logger == "log" {
+				t.Fatalf("logger %q: entry %d expected %s, got %s from %s", tc.logger, i, tc.want[i], e.Message, e.Logger)
+			}
+		}
+	}
+}
+
+// TestCustomTimeLayout 测试 WithDate 自定义格式按 TimeLayout 解析，无法解析的条目不会被时间过滤静默丢弃
+func TestCustomTimeLayout(t *testing.T) {
+	const layout = "02/01/2006 15:04:05"
+	dir := t.TempDir()
+	mgr := zlog.NewManager(zlog.WithLogDir(dir), zlog.WithAutoCleanup(false), zlog.WithDate(layout))
+	t.Cleanup(func() { _ = mgr.Close() })
+	mgr.Logger("billing").Info("charged")
+
+	since := time.Now().Add(-time.Hour)
+	entries, err := Query{Dir: dir, Logger: "billing", Since: since, TimeLayout: layout}.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Time.Before(since) {
+		t.Fatalf("expected the entry to be parsed with the custom layout, got %+v", entries)
+	}
+
+	entries, err = Query{Dir: dir, Logger: "billing", Since: since}.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || !entries[0].Time.IsZero() {
+		t.Fatalf("expected the unparsed entry to be kept with a zero time, got %+v", entries)
+	}
+}
+
+// TestDecodeUsesZlogLocation 在 ZLOG_TZ 与本地时区不同的子进程中确认 time 字段按 ZLOG_TZ 解析
+func TestDecodeUsesZlogLocation(t *testing.T) {
+	if os.Getenv("ZLOG_TZ") != "Asia/Tokyo" {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestDecodeUsesZlogLocation$")
+		cmd.Env = append(os.Environ(), "ZLOG_TZ=Asia/Tokyo", "TZ=UTC")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("subprocess failed: %v\n%s", err, out)
+		}
+		return
+	}
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip(err)
+	}
+	entry, err := Decode([]byte(`{"level":"info","time":"2025-01-15 08:00:00","message":"m"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2025, 1, 15, 8, 0, 0, 0, tokyo); !entry.Time.Equal(want) {
+		t.Fatalf("expected %s, got %s", want, entry.Time)
+	}
+}
@@ -1,9 +1,10 @@
 package zlog
 
 import (
-	"fmt"
 	"os"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -11,53 +12,125 @@ import (
 
 type configProvider func() Config
 
-// loggerRegistry 负责缓存 SugaredLogger，避免重复创建 zap Core。
+// loggerKey 区分同名 logger 的不同调用栈深度。
+type loggerKey struct {
+	name string
+	skip uint8
+}
+
+// loggerPair 是共享同一 core 与调用栈设置的 Logger 与 SugaredLogger。
+type loggerPair struct {
+	zap     *zap.Logger
+	sugar   *zap.SugaredLogger
+	renamed atomic.Bool // 曾有名称被替换不安全字符后映射到该 logger，直接使用该名称时需检查冲突
+}
+
+// loggerRegistry 负责缓存 logger，避免重复创建 zap Core；同名 logger 共享一个 core（及其文件 writer）。
 type loggerRegistry struct {
 	mu          sync.RWMutex
-	loggers     map[string]*zap.SugaredLogger
+	cores       map[string]*swapCore // 同名 logger 共享，重新配置时原子替换内部 core
+	loggers     map[loggerKey]*loggerPair
 	errorWriter zapcore.WriteSyncer
 	errorOnce   sync.Once
+	writers     []*resilientWriter            // 当前打开的全部文件 writer
+	owned       map[string][]*resilientWriter // 按 logger 名称记录使用的文件 writer，淘汰时释放
+	files       map[string]*sharedWriter      // 按路径共享文件 writer，点分层级中的后代可写入祖先的文件
+	onDiskFault func(DiskFault, error)
 	level       *zap.AtomicLevel
+	escalated   atomic.Value // map[string]zapcore.Level，SetLoggerLevelFor 设置的临时等级
 	cfgFn       configProvider
+	metrics     *metricsRegistry
+	pending     []pendingReport // 持有 r.mu 时产生的打开失败，释放锁后上报
 }
 
 // newLoggerRegistry 构造一个空的日志注册表。
 func newLoggerRegistry(level *zap.AtomicLevel, cfgFn configProvider) *loggerRegistry {
 	return &loggerRegistry{
-		loggers: make(map[string]*zap.SugaredLogger),
+		cores:   make(map[string]*swapCore),
+		loggers: make(map[loggerKey]*loggerPair),
+		owned:   make(map[string][]*resilientWriter),
+		files:   make(map[string]*sharedWriter),
 		level:   level,
 		cfgFn:   cfgFn,
+		metrics: newMetricsRegistry(),
 	}
 }
 
-// get 返回已存在的 logger，未命中时返回 false。
-func (r *loggerRegistry) get(name string) (*zap.SugaredLogger, bool) {
+// names 返回当前登记的 logger 名称，已淘汰的 logger 在重新使用前不计入。
+func (r *loggerRegistry) names() []string {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	logger, ok := r.loggers[name]
-	return logger, ok
+	names := make([]string, 0, len(r.cores))
+	for name := range r.cores {
+		names = append(names, name)
+	}
+	return names
 }
 
-// getOrCreate 获取或懒加载指定名称的 logger。
-func (r *loggerRegistry) getOrCreate(name string, skipCaller uint8) *zap.SugaredLogger {
-	if logger, ok := r.get(name); ok {
-		return logger
+// get 返回已存在的 core，未命中时返回 false。
+func (r *loggerRegistry) get(name string) (zapcore.Core, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	core, ok := r.cores[name]
+	if !ok {
+		return nil, false
 	}
+	return core, true
+}
+
+// lookup 返回已缓存的 logger。
+func (r *loggerRegistry) lookup(name string, skipCaller uint8) (*loggerPair, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	pair, ok := r.loggers[loggerKey{name: name, skip: skipCaller}]
+	return pair, ok
+}
 
+// getOrCreate 获取或懒加载指定名称与调用栈深度的 logger。
+func (r *loggerRegistry) getOrCreate(name string, skipCaller uint8) *loggerPair {
+	if pair, ok := r.lookup(name, skipCaller); ok {
+		return pair
+	}
+
+	key := loggerKey{name: name, skip: skipCaller}
 	r.mu.Lock()
-	defer r.mu.Unlock()
-	if logger, ok := r.loggers[name]; ok {
-		return logger
+	if pair, ok := r.loggers[key]; ok {
+		r.mu.Unlock()
+		return pair
+	}
+
+	cfg := r.cfgFn()
+	core, ok := r.cores[name]
+	var evicted []*resilientWriter
+	if !ok {
+		core = newSwapCore(r.buildCore(cfg, name))
+		core.touch(time.Now())
+		r.cores[name] = core
+		evicted = r.enforceMaxLoggersLocked(cfg, name)
+	}
+
+	caller := []zap.Option{zap.AddCaller()}
+	if skipCaller > 0 {
+		caller = append(caller, zap.AddCallerSkip(int(skipCaller)))
+	}
+
+	// 确定 logger 名称用于终端输出的 f 字段，默认 logger 显示为 "log"
+	loggerName := name
+	if name == cfg.DefaultLoggerName {
+		loggerName = "log"
 	}
+	logger := zap.New(core, caller...).Named(loggerName)
+	pair := &loggerPair{zap: logger, sugar: logger.Sugar()}
+	r.loggers[key] = pair
+	r.mu.Unlock()
 
-	logger := r.buildLogger(name, skipCaller)
-	r.loggers[name] = logger
-	return logger
+	retireWriters(evicted)
+	r.reportPending()
+	return pair
 }
 
-// buildLogger 构建底层 zap core，并根据环境级别配置 writer。
-func (r *loggerRegistry) buildLogger(name string, skipCaller uint8) *zap.SugaredLogger {
-	cfg := r.cfgFn()
+// buildCore 构建底层 zap core，并根据环境级别配置 writer。
+func (r *loggerRegistry) buildCore(cfg Config, name string) zapcore.Core {
 
 	// 基础 encoder 配置
 	baseEncoderConfig := zapcore.EncoderConfig{
@@ -73,85 +146,276 @@ func (r *loggerRegistry) buildLogger(name string, skipCaller uint8) *zap.Sugared
 		EncodeCaller:   zapcore.ShortCallerEncoder,
 	}
 
-	// 文件 encoder：不带 f 字段
+	// 文件 encoder：默认不带 f 字段，多个 logger 共享文件时带上以区分来源
+	owner := cfg.fileOwner(name)
 	fileEncoderConfig := baseEncoderConfig
+	if cfg.sharesFile(name, owner) {
+		fileEncoderConfig.NameKey = "f"
+	}
 	fileEncoder := zapcore.NewJSONEncoder(fileEncoderConfig)
 
+	// 共享错误文件汇集所有 logger 的错误，固定带 f 字段以便按 logger 查询
+	errorEncoderConfig := baseEncoderConfig
+	errorEncoderConfig.NameKey = "f"
+	errorEncoder := zapcore.NewJSONEncoder(errorEncoderConfig)
+
 	// 终端 encoder：固定带 f 字段
 	consoleEncoderConfig := baseEncoderConfig
 	consoleEncoderConfig.NameKey = "f"
 	consoleEncoder := zapcore.NewJSONEncoder(consoleEncoderConfig)
 
-	// 确定 logger 名称用于终端输出
-	loggerName := name
-	if name == cfg.DefaultLoggerName {
-		loggerName = "log" // 默认 logger 显示为 "log"
-	}
-
-	var core zapcore.Core
+	var cores []zapcore.Core
+	metrics := r.metrics.logger(name)
+	level := r.levelFor(cfg, name)
 
 	// 如果设置了仅输出到终端模式
 	if cfg.ConsoleOnly {
 		// 只输出到 stdout，使用带 f 字段的 encoder
-		core = zapcore.NewCore(consoleEncoder, zapcore.AddSync(os.Stdout), r.level)
+		cores = append(cores, zapcore.NewCore(consoleEncoder, zapcore.AddSync(os.Stdout), level))
 	} else {
 		// 默认模式：写文件 + 可能的终端输出
-		infoWriter, _ := newInfoWriter(cfg, logFilePath("%s_info.log", name))
-		errorWriter := r.ensureErrorWriter(cfg)
+		errorPath := logFilePath("%s.log", cfg.ErrorLoggerName)
+		targets := append(r.fileTargets(cfg, owner, level), fileTarget{path: errorPath, enabler: zapcore.ErrorLevel})
+		// 文件的切割次数与大小计入文件所属的 logger
+		fileMetrics := r.metrics.logger(owner)
 
-		// 文件输出使用不带 f 的 encoder
-		fileCores := []zapcore.Core{
-			zapcore.NewCore(fileEncoder, zapcore.AddSync(infoWriter), r.level),
-			zapcore.NewCore(fileEncoder, zapcore.AddSync(errorWriter), zapcore.ErrorLevel),
+		// 同一路径只打开一个 writer
+		var ownWriters []fileSizer
+		for _, target := range mergeFileTargets(targets) {
+			var writer zapcore.WriteSyncer
+			encoder := fileEncoder
+			if target.path == errorPath {
+				writer = r.ensureErrorWriter(cfg)
+				encoder = errorEncoder
+			} else {
+				rw := r.acquireFileWriter(cfg, target.path, fileMetrics)
+				ownWriters = append(ownWriters, rw)
+				r.owned[name] = append(r.owned[name], rw)
+				writer = rw
+			}
+			cores = append(cores, zapcore.NewCore(encoder, metrics.countWrites(writer), target.enabler))
 		}
+		fileMetrics.setWriters(ownWriters)
 
 		// 如果是 Debug 模式，同时输出到终端（使用带 f 的 encoder）
 		if cfg.Env == ENV_DEBUG || cfg.Level == zapcore.DebugLevel {
-			fileCores = append(fileCores,
-				zapcore.NewCore(consoleEncoder, zapcore.AddSync(os.Stdout), r.level),
+			cores = append(cores,
+				zapcore.NewCore(consoleEncoder, zapcore.AddSync(os.Stdout), level),
 			)
 		}
+	}
 
-		core = zapcore.NewTee(fileCores...)
+	// 按条目统计等级与丢失；采样在统计之前，被丢弃的条目单独计数
+	return cfg.Sampling.sample(newMeteredCore(metrics, cores...), metrics)
+}
+
+// fileTarget 描述一个文件输出目标及其启用的等级。
+type fileTarget struct {
+	path    string
+	enabler zapcore.LevelEnabler
+}
+
+// fileTargets 返回文件前缀 name 的输出目标：默认写 <name>_info.log，
+// 开启等级拆分后按 LevelFiles 写入 <name>_<suffix>.log，开启独立错误文件时追加 <name>_error.log。
+func (r *loggerRegistry) fileTargets(cfg Config, name string, level zapcore.LevelEnabler) []fileTarget {
+	var targets []fileTarget
+	if !cfg.SplitLevels {
+		targets = append(targets, fileTarget{path: logFilePath("%s_info.log", name), enabler: level})
+	} else {
+		levelFiles := cfg.LevelFiles
+		if len(levelFiles) == 0 {
+			levelFiles = DefaultLevelFiles()
+		}
+		for _, lf := range levelFiles {
+			targets = append(targets, fileTarget{
+				path:    logFilePath("%s_%s.log", name, lf.Name),
+				enabler: levelRange(level, lf.Min, lf.Max),
+			})
+		}
 	}
 
-	caller := []zap.Option{zap.AddCaller()}
-	if skipCaller > 0 {
-		caller = append(caller, zap.AddCallerSkip(int(skipCaller)))
+	if cfg.loggerErrorFileEnabled(name) {
+		targets = append(targets, fileTarget{path: logFilePath("%s_error.log", name), enabler: zapcore.ErrorLevel})
 	}
+	return targets
+}
 
-	// 添加 logger name 用于终端输出的 f 字段
-	logger := zap.New(core, caller...).Named(loggerName)
+// mergeFileTargets 合并指向同一文件的目标，避免同一文件被多个 writer 重复写入。
+func mergeFileTargets(targets []fileTarget) []fileTarget {
+	merged := make([]fileTarget, 0, len(targets))
+	index := make(map[string]int, len(targets))
+	for _, target := range targets {
+		i, ok := index[target.path]
+		if !ok {
+			index[target.path] = len(merged)
+			merged = append(merged, target)
+			continue
+		}
+		prev := merged[i].enabler
+		next := target.enabler
+		merged[i].enabler = zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+			return prev.Enabled(lvl) || next.Enabled(lvl)
+		})
+	}
+	return merged
+}
+
+// levelRange 仅在等级位于 [min, max] 且满足 base 等级时启用。
+func levelRange(base zapcore.LevelEnabler, min, max zapcore.Level) zap.LevelEnablerFunc {
+	return func(lvl zapcore.Level) bool {
+		return lvl >= min && lvl <= max && base.Enabled(lvl)
+	}
+}
+
+// openFileWriter 创建按 cfg 错误处理策略兜底与重试的文件 writer，调用方需持有 r.mu。
+// 打开失败的上报推迟到 reportPending，ErrorHandler 中可以继续写日志。
+func (r *loggerRegistry) openFileWriter(cfg Config, path string, open func(Config, string) (zapcore.WriteSyncer, error)) *resilientWriter {
+	policy := cfg.writePolicy()
+	policy.onDiskFault = r.onDiskFault
+	w, report := openResilientWriter(path, func() (zapcore.WriteSyncer, error) { return open(cfg, path) }, policy)
+	if report != nil {
+		r.pending = append(r.pending, pendingReport{writer: w, err: report})
+	}
+	r.writers = append(r.writers, w)
+	return w
+}
 
-	return logger.Sugar()
+// pendingReport 是持有 r.mu 时产生、等待在锁外上报的打开失败。
+type pendingReport struct {
+	writer *resilientWriter
+	err    *WriteError
+}
+
+// reportPending 在锁外上报打开失败，调用方不能持有 r.mu。
+func (r *loggerRegistry) reportPending() {
+	r.mu.Lock()
+	pending := r.pending
+	r.pending = nil
+	r.mu.Unlock()
+	for _, p := range pending {
+		p.writer.report(p.err)
+	}
+}
+
+// sharedWriter 是按路径共享的文件 writer 及其引用数。
+type sharedWriter struct {
+	writer *resilientWriter
+	refs   int
+}
+
+// acquireFileWriter 返回 path 对应的文件 writer，已打开时增加引用，调用方需持有 r.mu。
+func (r *loggerRegistry) acquireFileWriter(cfg Config, path string, metrics *loggerMetrics) *resilientWriter {
+	if shared, ok := r.files[path]; ok {
+		shared.refs++
+		return shared.writer
+	}
+	rw := r.openFileWriter(cfg, path, newInfoWriter)
+	rw.setHooks(metrics.rotated, metrics.writeFailed, metrics.drop)
+	r.files[path] = &sharedWriter{writer: rw, refs: 1}
+	return rw
+}
+
+// releaseFileWriters 减少引用，返回不再被任何 logger 使用、需要在锁外关闭的 writer，调用方需持有 r.mu。
+func (r *loggerRegistry) releaseFileWriters(writers []*resilientWriter) []*resilientWriter {
+	var closing []*resilientWriter
+	for _, w := range writers {
+		shared, ok := r.files[w.path]
+		if !ok || shared.writer != w {
+			continue
+		}
+		if shared.refs--; shared.refs == 0 {
+			delete(r.files, w.path)
+			closing = append(closing, w)
+		}
+	}
+	if len(closing) == 0 {
+		return nil
+	}
+
+	drop := make(map[*resilientWriter]bool, len(closing))
+	for _, w := range closing {
+		drop[w] = true
+	}
+	kept := r.writers[:0]
+	for _, w := range r.writers {
+		if !drop[w] {
+			kept = append(kept, w)
+		}
+	}
+	r.writers = kept
+	return closing
 }
 
 // ensureErrorWriter 构建共享的 error writer，保证只初始化一次。
 func (r *loggerRegistry) ensureErrorWriter(cfg Config) zapcore.WriteSyncer {
 	r.errorOnce.Do(func() {
-		writer, err := newErrorWriter(cfg, logFilePath("%s.log", cfg.ErrorLoggerName))
-		if err != nil || writer == nil {
-			fmt.Fprintf(os.Stderr, "zlog: failed to create error writer: %v\n", err)
-			r.errorWriter = zapcore.AddSync(os.Stderr)
-		} else {
-			r.errorWriter = writer
-		}
+		rw := r.openFileWriter(cfg, logFilePath("%s.log", cfg.ErrorLoggerName), newErrorWriter)
+		// 共享错误文件的切割、失败与大小计入 ErrorLoggerName
+		metrics := r.metrics.logger(cfg.ErrorLoggerName)
+		rw.setHooks(metrics.rotated, metrics.writeFailed, metrics.drop)
+		metrics.setWriters([]fileSizer{rw})
+		r.errorWriter = rw
 	})
 	return r.errorWriter
 }
 
-func (r *loggerRegistry) resetErrorWriter() {
+// health 汇总所有文件 writer 的状态。
+func (r *loggerRegistry) health() Health {
+	r.mu.RLock()
+	writers := append([]*resilientWriter(nil), r.writers...)
+	r.mu.RUnlock()
+
+	h := Health{Healthy: true, Writers: make([]WriterHealth, 0, len(writers))}
+	for _, w := range writers {
+		wh := w.health()
+		h.Healthy = h.Healthy && wh.Healthy
+		h.Writers = append(h.Writers, wh)
+	}
+	return h
+}
+
+// reset 按最新配置重建所有已创建 logger 的 core 并原子替换，随后关闭旧的文件 writer。
+// 调用方保存的 logger 无需重新获取即可使用新配置。
+func (r *loggerRegistry) reset() {
 	r.mu.Lock()
-	defer r.mu.Unlock()
+	old := r.writers
+	r.writers = nil
+	r.owned = make(map[string][]*resilientWriter)
+	r.files = make(map[string]*sharedWriter)
 	r.errorWriter = nil
 	r.errorOnce = sync.Once{}
+
+	cfg := r.cfgFn()
+	for name, core := range r.cores {
+		if _, dormant := core.inner().(*dormantCore); dormant {
+			// 已淘汰的 logger 保持休眠，下次写日志时按新配置重建
+			continue
+		}
+		core.swap(r.buildCore(cfg, name))
+	}
+	old = append(old, r.enforceMaxLoggersLocked(cfg, "")...)
+	r.mu.Unlock()
+
+	closeWriters(old)
+	r.reportPending()
 }
 
-// reset 清空所有缓存的 logger，强制下次获取时重新创建。
-func (r *loggerRegistry) reset() {
+// close 刷新并关闭所有文件 writer，之后的写入按兜底策略处理。
+func (r *loggerRegistry) close() error {
 	r.mu.Lock()
-	defer r.mu.Unlock()
-	r.loggers = make(map[string]*zap.SugaredLogger)
+	old := r.writers
+	r.writers = nil
+	r.owned = make(map[string][]*resilientWriter)
+	r.files = make(map[string]*sharedWriter)
 	r.errorWriter = nil
 	r.errorOnce = sync.Once{}
+	r.mu.Unlock()
+
+	var firstErr error
+	for _, w := range old {
+		if err := w.close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
 }
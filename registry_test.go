@@ -0,0 +1,152 @@
+package zlog
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TestZapLoggerSharesCore 测试 ZapLogger 与 Logger 共享 core，并按调用栈深度分别缓存
+func TestZapLoggerSharesCore(t *testing.T) {
+	mgr, tmpDir := newTestManager(t)
+	if mgr.ZapLogger("api") != mgr.ZapLogger("api") || mgr.Logger("api") != mgr.Logger("api") {
+		t.Fatal("expected cached loggers")
+	}
+	if mgr.Logger("api") == mgr.Logger("api", "") {
+		t.Fatal("loggers with different caller skip must not share a cache entry")
+	}
+	if mgr.ZapLogger("api").Core() != mgr.Logger("api", "").Desugar().Core() {
+		t.Fatal("loggers with the same name should share one core")
+	}
+
+	mgr.ZapLogger("api").Info("typed", zap.String("k", "v"))
+	mgr.Logger("api").Infow("sugared", "k", "v")
+	wrapped := func() { mgr.Logger("api", "").Info("wrapped") }
+	_, _, line, _ := runtime.Caller(0)
+	wrapped()
+	_ = mgr.Sync("api")
+
+	content := readTodayLog(t, tmpDir, "api_info")
+	lines := strings.Split(strings.TrimSpace(content), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %q", content)
+	}
+	for _, line := range lines[:2] {
+		if !strings.Contains(line, "registry_test.go") {
+			t.Fatalf("unexpected caller: %s", line)
+		}
+	}
+	// 第二个参数跳过一层封装，调用位置指向封装函数的调用方
+	if want := fmt.Sprintf("registry_test.go:%d", line+1); !strings.Contains(lines[2], want) {
+		t.Fatalf("caller skip not applied: %s", lines[2])
+	}
+	if len(mgr.Health().Writers) != 2 {
+		t.Fatalf("expected one info writer and the shared error writer, got %+v", mgr.Health().Writers)
+	}
+}
+
+// TestStoredLoggerFollowsReconfiguration 测试调用方保存的 logger 在重新配置后使用新的 core，旧 writer 被关闭
+func TestStoredLoggerFollowsReconfiguration(t *testing.T) {
+	mgr, firstDir := newTestManager(t)
+	secondDir := t.TempDir()
+	logger := mgr.Logger("api")
+	child := logger.With("req", "r1")
+	logger.Info("before")
+
+	if err := mgr.SetLogDir(secondDir); err != nil {
+		t.Fatalf("SetLogDir: %v", err)
+	}
+	for _, w := range mgr.Health().Writers {
+		if !strings.HasPrefix(w.Path, secondDir) {
+			t.Fatalf("writer for the old dir still open: %+v", w)
+		}
+	}
+
+	logger.Info("after dir change")
+	child.Info("child after dir change")
+	if content := readTodayLog(t, firstDir, "api_info"); strings.Contains(content, "after dir change") {
+		t.Fatalf("stored logger still writes to the old dir: %q", content)
+	}
+	content := readTodayLog(t, secondDir, "api_info")
+	if !strings.Contains(content, `"message":"after dir change"`) || !strings.Contains(content, `"req":"r1"`) {
+		t.Fatalf("stored loggers did not follow SetLogDir: %q", content)
+	}
+
+	t.Log("调整等级后已保存的 logger 立即生效")
+	mgr.SetLevel(zapcore.WarnLevel)
+	logger.Info("filtered")
+	logger.Warn("kept")
+	content = readTodayLog(t, secondDir, "api_info")
+	if strings.Contains(content, "filtered") || !strings.Contains(content, "kept") {
+		t.Fatalf("stored logger ignored SetLevel: %q", content)
+	}
+
+	t.Log("仅终端模式下不再写文件，也不再持有文件 writer")
+	mgr.SetConsoleOnly(true)
+	logger.Warn("console only")
+	if content := readTodayLog(t, secondDir, "api_info"); strings.Contains(content, "console only") {
+		t.Fatalf("stored logger still writes files in console-only mode: %q", content)
+	}
+	if n := len(mgr.Health().Writers); n != 0 {
+		t.Fatalf("expected no file writers in console-only mode, got %d", n)
+	}
+}
+
+// TestManagerClose 测试 Close 关闭全部文件 writer
+func TestManagerClose(t *testing.T) {
+	mgr, tmpDir := newTestManager(t, WithWriteFallback(FallbackDiscard))
+	logger := mgr.Logger("api")
+	logger.Info("before close")
+	if err := mgr.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if n := len(mgr.Health().Writers); n != 0 {
+		t.Fatalf("expected no open writers after Close, got %d", n)
+	}
+	logger.Info("after close")
+	if content := readTodayLog(t, tmpDir, "api_info"); strings.Contains(content, "after close") {
+		t.Fatalf("write after Close reached the file: %q", content)
+	}
+}
+
+func benchmarkManager(b *testing.B) *Manager {
+	origDir := logDir()
+	b.Cleanup(func() { setLogDir(origDir) })
+	return NewManager(WithLogDir(b.TempDir()), WithAutoCleanup(false))
+}
+
+// BenchmarkSugaredLogger 通过 SugaredLogger 写结构化日志
+func BenchmarkSugaredLogger(b *testing.B) {
+	mgr := benchmarkManager(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		mgr.Logger("bench").Infow("request done", "path", "/v1/order", "status", 200, "latency_ms", 3.5)
+	}
+}
+
+// BenchmarkZapLogger 通过 *zap.Logger 写强类型字段日志
+func BenchmarkZapLogger(b *testing.B) {
+	mgr := benchmarkManager(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		mgr.ZapLogger("bench").Info("request done",
+			zap.String("path", "/v1/order"), zap.Int("status", 200), zap.Float64("latency_ms", 3.5))
+	}
+}
+
+// BenchmarkZapLoggerDisabled 低于当前等级的日志应接近零开销
+func BenchmarkZapLoggerDisabled(b *testing.B) {
+	mgr := benchmarkManager(b)
+	logger := mgr.ZapLogger("bench")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.Debug("skipped", zap.Int("i", i))
+	}
+}
@@ -0,0 +1,428 @@
+package zlog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// WriteFallback 描述日志文件不可写时的兜底策略。
+type WriteFallback int
+
+const (
+	FallbackStderr  WriteFallback = iota // 写入 stderr（默认）
+	FallbackDiscard                      // 丢弃并计入 dropped 指标
+	FallbackBuffer                       // 缓存在内存环形缓冲区，恢复后先写回文件；缓冲区满时丢弃最旧条目
+)
+
+// defaultFallbackBufferSize 是 FallbackBuffer 默认缓存的条目数。
+const defaultFallbackBufferSize = 1000
+
+// 写入失败的操作类型。
+const (
+	WriteOpOpen  = "open"
+	WriteOpWrite = "write"
+)
+
+// WriteError 描述一次日志文件打开或写入失败。
+type WriteError struct {
+	Path  string    // 日志链接文件路径，例如 logs/api_info.log
+	Op    string    // WriteOpOpen 或 WriteOpWrite
+	Err   error     // 底层错误
+	Fault DiskFault // 磁盘已满或只读时非 DiskOK
+	Time  time.Time // 发生时间
+}
+
+// Error 实现 error 接口。
+func (e WriteError) Error() string {
+	return fmt.Sprintf("zlog: %s %s: %v", e.Op, e.Path, e.Err)
+}
+
+// Unwrap 返回底层错误。
+func (e WriteError) Unwrap() error {
+	return e.Err
+}
+
+// Health 描述实例所有文件 writer 的状态。
+type Health struct {
+	Healthy bool           // 所有 writer 均正常写文件
+	Writers []WriterHealth // 按创建顺序
+}
+
+// WriterHealth 描述单个文件 writer 的状态。
+type WriterHealth struct {
+	Path        string
+	Healthy     bool      // 正常写文件；false 表示正在使用兜底策略
+	LastError   error     // 最近一次失败，恢复后保留供排查
+	FailedSince time.Time // 进入兜底状态的时间，健康时为零值
+	Failures    uint64    // 累计失败次数
+	NextRetry   time.Time // 下一次尝试重新打开文件的时间
+	Fault       DiskFault // 当前的磁盘故障类型
+	Buffered    int       // FallbackBuffer 中等待写回的条目数
+	Dropped     uint64    // FallbackBuffer 溢出丢弃的条目数
+}
+
+// 默认的重新打开退避时间。
+const (
+	defaultReopenBackoff    = time.Second
+	defaultReopenMaxBackoff = time.Minute
+)
+
+// writePolicy 是 resilientWriter 使用的错误处理策略。
+type writePolicy struct {
+	handler     func(WriteError)
+	fallback    WriteFallback
+	bufferSize  int
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+	onDiskFault func(DiskFault, error) // 检测到磁盘故障时回调（锁外调用）
+}
+
+// writePolicy 从配置中提取写入错误处理策略。
+func (cfg Config) writePolicy() writePolicy {
+	p := writePolicy{
+		handler:    cfg.ErrorHandler,
+		fallback:   cfg.WriteFallback,
+		bufferSize: cfg.FallbackBufferSize,
+		minBackoff: cfg.ReopenBackoff,
+		maxBackoff: cfg.ReopenMaxBackoff,
+	}
+	if p.minBackoff <= 0 {
+		p.minBackoff = defaultReopenBackoff
+	}
+	if p.maxBackoff < p.minBackoff {
+		p.maxBackoff = p.minBackoff
+	}
+	return p
+}
+
+// resilientWriter 在文件不可写时切换到兜底策略，并按退避时间重新打开文件。
+type resilientWriter struct {
+	path   string
+	open   func() (zapcore.WriteSyncer, error)
+	policy writePolicy
+
+	mu          sync.Mutex
+	current     zapcore.WriteSyncer // nil 表示处于兜底状态
+	lastErr     error
+	failedSince time.Time
+	failures    uint64
+	backoff     time.Duration
+	nextRetry   time.Time
+	fault       DiskFault
+	buffer      *ringBuffer
+	dropped     uint64
+	rotated     func()
+	writeFailed func()
+	onDrop      func()
+	closed      bool
+	retired     bool                // 因淘汰而关闭，在途日志仍可临时打开文件写入
+	late        zapcore.WriteSyncer // 淘汰后为在途日志重新打开的文件，lateWriteLinger 后关闭
+}
+
+// lateWriteLinger 是被淘汰的 writer 为在途日志重新打开文件后保持打开的时间，期间的在途日志复用同一文件。
+const lateWriteLinger = time.Second
+
+// errWriterClosed 表示 writer 已在重新配置或 Close 时关闭。
+var errWriterClosed = errors.New("zlog: writer closed")
+
+// newResilientWriter 立即尝试打开文件，失败时进入兜底状态并上报。
+func newResilientWriter(path string, open func() (zapcore.WriteSyncer, error), policy writePolicy) *resilientWriter {
+	w, report := openResilientWriter(path, open, policy)
+	w.report(report)
+	return w
+}
+
+// openResilientWriter 与 newResilientWriter 相同，但把打开失败返回给调用方，由调用方在释放自己的锁后上报。
+func openResilientWriter(path string, open func() (zapcore.WriteSyncer, error), policy writePolicy) (*resilientWriter, *WriteError) {
+	w := &resilientWriter{path: path, open: open, policy: policy}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w, w.reopenLocked(time.Now())
+}
+
+// setHooks 设置切割、写入失败与缓冲区丢弃的指标回调。
+func (w *resilientWriter) setHooks(rotated, writeFailed, onDrop func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.rotated, w.writeFailed, w.onDrop = rotated, writeFailed, onDrop
+	if rw, ok := w.current.(*rotateWriter); ok {
+		rw.setRotateHook(rotated)
+	}
+}
+
+// Write 写入文件；文件不可用时按策略兜底，并在退避时间到达后尝试重新打开。
+func (w *resilientWriter) Write(p []byte) (int, error) {
+	var report *WriteError
+	defer func() { w.report(report) }()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		if w.retired {
+			if n, ok := w.writeLateLocked(p); ok {
+				return n, nil
+			}
+		}
+		// 重新配置后仍在途的少量日志交给兜底策略，不再重新打开文件
+		return w.fallbackLocked(p, 0)
+	}
+
+	now := time.Now()
+	if w.current == nil && !now.Before(w.nextRetry) {
+		report = w.reopenLocked(now)
+	}
+	written := 0
+	if w.current != nil {
+		// 先写回降级期间缓存的条目，保证顺序
+		err := w.flushBufferLocked()
+		if err == nil {
+			if written, err = w.current.Write(p); err == nil {
+				w.backoff = 0
+				return written, nil
+			}
+		}
+		report = w.failLocked(WriteOpWrite, err, now)
+	}
+	return w.fallbackLocked(p, written)
+}
+
+// fallbackLocked 按兜底策略处理无法写入文件的条目，written 是已写入文件的字节数。
+func (w *resilientWriter) fallbackLocked(p []byte, written int) (int, error) {
+	switch w.policy.fallback {
+	case FallbackDiscard:
+		// 返回错误，由 countingWriter 计入写入失败与丢弃
+		return 0, w.lastErr
+	case FallbackBuffer:
+		if w.buffer == nil {
+			w.buffer = newRingBuffer(w.policy.bufferSize)
+		}
+		// 只缓存未写入的部分，恢复后接着写完这一行，避免重复
+		if w.buffer.push(p[written:]) {
+			w.dropped++
+			if w.onDrop != nil {
+				w.onDrop()
+			}
+		}
+		if w.writeFailed != nil {
+			w.writeFailed()
+		}
+		return len(p), nil
+	default:
+		if w.writeFailed != nil {
+			w.writeFailed()
+		}
+		return os.Stderr.Write(p)
+	}
+}
+
+// flushBufferLocked 将环形缓冲区中的条目写回当前文件。
+func (w *resilientWriter) flushBufferLocked() error {
+	if w.buffer == nil || w.buffer.len() == 0 {
+		return nil
+	}
+	return w.buffer.flush(w.current.Write)
+}
+
+// Sync 写回缓存并刷新当前文件；降级中且到了重试时间时先尝试重新打开，避免没有新日志时缓存一直留在内存。
+func (w *resilientWriter) Sync() error {
+	var report *WriteError
+	defer func() { w.report(report) }()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		if w.late != nil {
+			return w.late.Sync()
+		}
+		return nil
+	}
+	now := time.Now()
+	if w.current == nil && w.buffered() && !now.Before(w.nextRetry) {
+		report = w.reopenLocked(now)
+	}
+	if w.current == nil {
+		return nil
+	}
+	if err := w.flushBufferLocked(); err != nil {
+		report = w.failLocked(WriteOpWrite, err, now)
+		return err
+	}
+	return w.current.Sync()
+}
+
+// buffered 判断 FallbackBuffer 中是否有等待写回的条目，调用方需持有 w.mu。
+func (w *resilientWriter) buffered() bool {
+	return w.buffer != nil && w.buffer.len() > 0
+}
+
+// close 写回缓存后关闭当前文件，之后不再重新打开；降级中仍有缓存时忽略退避最后尝试一次重新打开。
+func (w *resilientWriter) close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.closeLocked()
+}
+
+// closeLocked 见 close，调用方需持有 w.mu。
+func (w *resilientWriter) closeLocked() error {
+	if w.closed {
+		return nil
+	}
+	if w.current == nil && w.buffered() {
+		_ = w.reopenLocked(time.Now())
+	}
+	var err error
+	if w.current != nil {
+		err = w.flushBufferLocked()
+		if serr := w.current.Sync(); err == nil {
+			err = serr
+		}
+		closeWriter(w.current)
+		w.current = nil
+	}
+	w.closed = true
+	w.lastErr = errWriterClosed
+	return err
+}
+
+// retire 与 close 相同，但之后的写入会临时打开文件写完，lateWriteLinger 后再关闭，
+// 用于淘汰 logger：Check 之后、Write 之前被淘汰的在途日志仍写入原文件。
+func (w *resilientWriter) retire() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.retired = true
+	return w.closeLocked()
+}
+
+// writeLateLocked 写入淘汰后的在途日志：首次写入时重新打开文件并保持 lateWriteLinger，
+// 期间的在途日志复用同一文件，避免每条日志都重新打开；打开或写入失败时返回 false。
+func (w *resilientWriter) writeLateLocked(p []byte) (int, bool) {
+	if w.late == nil {
+		ws, err := w.open()
+		if err != nil || ws == nil {
+			return 0, false
+		}
+		w.late = ws
+		time.AfterFunc(lateWriteLinger, w.closeLate)
+	}
+	n, err := w.late.Write(p)
+	if err != nil {
+		closeWriter(w.late)
+		w.late = nil
+		return 0, false
+	}
+	return n, true
+}
+
+// closeLate 刷新并关闭为在途日志重新打开的文件。
+func (w *resilientWriter) closeLate() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.late != nil {
+		_ = w.late.Sync()
+		closeWriter(w.late)
+		w.late = nil
+	}
+}
+
+// reopenLocked 尝试打开文件，成功时返回 nil，失败时返回需要上报的错误。
+func (w *resilientWriter) reopenLocked(now time.Time) *WriteError {
+	ws, err := w.open()
+	if err != nil || ws == nil {
+		if err == nil {
+			err = errors.New("nil writer")
+		}
+		return w.failLocked(WriteOpOpen, err, now)
+	}
+	if rw, ok := ws.(*rotateWriter); ok && w.rotated != nil {
+		rw.setRotateHook(w.rotated)
+	}
+	w.current = ws
+	w.failedSince = time.Time{}
+	w.fault = DiskOK
+	return nil
+}
+
+// failLocked 关闭当前文件、进入兜底状态并计算下一次重试时间。
+func (w *resilientWriter) failLocked(op string, err error, now time.Time) *WriteError {
+	closeWriter(w.current)
+	w.current = nil
+	w.lastErr = err
+	w.fault = DetectDiskFault(err)
+	w.failures++
+	if w.failedSince.IsZero() {
+		w.failedSince = now
+	}
+
+	if w.backoff == 0 {
+		w.backoff = w.policy.minBackoff
+	} else if w.backoff *= 2; w.backoff > w.policy.maxBackoff {
+		w.backoff = w.policy.maxBackoff
+	}
+	w.nextRetry = now.Add(w.backoff)
+	return &WriteError{Path: w.path, Op: op, Err: err, Fault: w.fault, Time: now}
+}
+
+// report 在锁外调用错误回调，允许回调中再次写日志。
+func (w *resilientWriter) report(e *WriteError) {
+	if e == nil {
+		return
+	}
+	if e.Fault != DiskOK && w.policy.onDiskFault != nil {
+		w.policy.onDiskFault(e.Fault, e.Err)
+	}
+	if w.policy.handler != nil {
+		w.policy.handler(*e)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%v, falling back until the file can be reopened\n", e)
+}
+
+// health 返回 writer 当前状态。
+func (w *resilientWriter) health() WriterHealth {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	h := WriterHealth{
+		Path:        w.path,
+		Healthy:     w.current != nil,
+		LastError:   w.lastErr,
+		FailedSince: w.failedSince,
+		Failures:    w.failures,
+		Fault:       w.fault,
+		Dropped:     w.dropped,
+	}
+	if w.buffer != nil {
+		h.Buffered = w.buffer.len()
+	}
+	if !h.Healthy {
+		h.NextRetry = w.nextRetry
+	}
+	return h
+}
+
+// fileSize 返回当前文件大小，兜底状态下为 0。
+func (w *resilientWriter) fileSize() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if rw, ok := w.current.(*rotateWriter); ok {
+		return rw.fileSize()
+	}
+	return 0
+}
+
+// closeWriter 关闭支持 io.Closer 的 writer。
+func closeWriter(ws zapcore.WriteSyncer) {
+	if c, ok := ws.(interface{ Close() error }); ok {
+		_ = c.Close()
+	}
+}
+
+// Health 返回当前实例所有文件 writer 的状态。
+func (m *Manager) Health() Health {
+	return m.registry.health()
+}
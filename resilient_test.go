@@ -0,0 +1,232 @@
+package zlog
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// flakyWriter 在 fail 为 true 时写入失败。
+type flakyWriter struct {
+	mu   sync.Mutex
+	fail bool
+	buf  bytes.Buffer
+}
+
+func (w *flakyWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.fail {
+		return 0, errors.New("write failed")
+	}
+	return w.buf.Write(p)
+}
+
+func (w *flakyWriter) Sync() error { return nil }
+
+// TestResilientWriterBackoff 测试兜底、退避重试与错误回调
+func TestResilientWriterBackoff(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []WriteError
+	)
+	policy := writePolicy{
+		handler: func(e WriteError) {
+			mu.Lock()
+			events = append(events, e)
+			mu.Unlock()
+		},
+		fallback:   FallbackDiscard,
+		minBackoff: 20 * time.Millisecond,
+		maxBackoff: 30 * time.Millisecond,
+	}
+
+	target := &flakyWriter{}
+	openErr := errors.New("permission denied")
+	open := func() (zapcore.WriteSyncer, error) {
+		if openErr != nil {
+			return nil, openErr
+		}
+		return target, nil
+	}
+
+	w := newResilientWriter("logs/api_info.log", open, policy)
+	if h := w.health(); h.Healthy || h.Failures != 1 || len(events) != 1 || events[0].Op != WriteOpOpen {
+		t.Fatalf("expected open failure, health=%+v events=%v", h, events)
+	}
+	if _, err := w.Write([]byte("lost\n")); err == nil {
+		t.Fatal("discard fallback should return the error")
+	}
+
+	t.Log("退避时间内不重试，到期后重新打开")
+	openErr = nil
+	if _, err := w.Write([]byte("still lost\n")); err == nil {
+		t.Fatal("should not reopen before backoff expires")
+	}
+	time.Sleep(25 * time.Millisecond)
+	if _, err := w.Write([]byte("ok\n")); err != nil {
+		t.Fatalf("expected reopen: %v", err)
+	}
+	if h := w.health(); !h.Healthy || !h.FailedSince.IsZero() || h.LastError == nil {
+		t.Fatalf("unexpected health after reopen: %+v", h)
+	}
+
+	t.Log("写入失败后进入兜底并上报")
+	target.fail = true
+	_, _ = w.Write([]byte("fail\n"))
+	h := w.health()
+	if h.Healthy || h.Failures != 2 || h.NextRetry.IsZero() {
+		t.Fatalf("unexpected health after write failure: %+v", h)
+	}
+	mu.Lock()
+	last := events[len(events)-1]
+	mu.Unlock()
+	if last.Op != WriteOpWrite || !strings.Contains(last.Error(), "write failed") {
+		t.Fatalf("unexpected event: %v", last)
+	}
+	if got := target.buf.String(); got != "ok\n" {
+		t.Fatalf("unexpected file content %q", got)
+	}
+}
+
+// TestRetiredWriterReopensOnce 测试淘汰后的在途日志只重新打开一次文件并复用，关闭后再次写入才重新打开
+func TestRetiredWriterReopensOnce(t *testing.T) {
+	target := &flakyWriter{}
+	opens := 0
+	open := func() (zapcore.WriteSyncer, error) {
+		opens++
+		return target, nil
+	}
+	w := newResilientWriter("logs/api_info.log", open, writePolicy{fallback: FallbackDiscard})
+	if err := w.retire(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 100; i++ {
+		if _, err := w.Write([]byte("late\n")); err != nil {
+			t.Fatalf("late write failed: %v", err)
+		}
+	}
+	if opens != 2 || strings.Count(target.buf.String(), "late") != 100 {
+		t.Fatalf("expected one reopen for all late writes, got %d opens and %q", opens, target.buf.String())
+	}
+
+	t.Log("保持时间到期后关闭，之后的在途日志再次打开")
+	w.closeLate()
+	if _, err := w.Write([]byte("later\n")); err != nil || opens != 3 {
+		t.Fatalf("expected a reopen after the linger, got %d opens: %v", opens, err)
+	}
+}
+
+// TestManagerHealthRecovers 测试日志目录不可写时兜底，目录恢复后自动写回文件
+func TestManagerHealthRecovers(t *testing.T) {
+	base := t.TempDir()
+	origDir := logDir()
+	t.Cleanup(func() { setLogDir(origDir) })
+
+	// 用普通文件占住日志目录路径，使目录无法创建
+	dir := filepath.Join(base, "logs")
+	if err := os.WriteFile(dir, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		mu     sync.Mutex
+		events []WriteError
+	)
+	mgr := NewManager(
+		WithLogDir(dir),
+		WithAutoCleanup(false),
+		WithWriteFallback(FallbackDiscard),
+		WithReopenBackoff(20*time.Millisecond, 20*time.Millisecond),
+		WithErrorHandler(func(e WriteError) {
+			mu.Lock()
+			events = append(events, e)
+			mu.Unlock()
+		}),
+	)
+	mgr.Logger("api").Info("lost")
+
+	health := mgr.Health()
+	if health.Healthy || len(health.Writers) == 0 {
+		t.Fatalf("expected unhealthy writers: %+v", health)
+	}
+	mu.Lock()
+	if len(events) == 0 || events[0].Op != WriteOpOpen {
+		t.Fatalf("expected open error events, got %v", events)
+	}
+	mu.Unlock()
+	if dropped := mgr.Stats().Loggers["api"].Dropped; dropped == 0 {
+		t.Fatal("expected dropped entries to be counted")
+	}
+
+	t.Log("恢复目录后自动写回文件")
+	if err := os.Remove(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	mgr.Logger("api").Info("recovered")
+
+	content := readTodayLog(t, dir, "api_info")
+	if !strings.Contains(content, "recovered") || strings.Contains(content, "lost") {
+		t.Fatalf("unexpected content: %q", content)
+	}
+	for _, w := range mgr.Health().Writers {
+		if strings.HasSuffix(w.Path, "api_info.log") && !w.Healthy {
+			t.Fatalf("api_info writer should be healthy: %+v", w)
+		}
+	}
+}
+
+// TestErrorHandlerCanLog 测试打开文件失败时 ErrorHandler 在注册表锁外调用，回调中可以继续写日志
+func TestErrorHandlerCanLog(t *testing.T) {
+	tmpDir := t.TempDir()
+	origDir := logDir()
+	t.Cleanup(func() { setLogDir(origDir) })
+
+	// 日志目录位于普通文件之下，任何日志文件都无法打开
+	blocker := filepath.Join(tmpDir, "blocker")
+	if err := os.WriteFile(blocker, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var mgr *Manager
+	var mu sync.Mutex
+	var reported []string
+	handler := func(e WriteError) {
+		mu.Lock()
+		reported = append(reported, e.Path)
+		mu.Unlock()
+		mgr.Logger("alerts").Infow("log file unavailable", "path", e.Path)
+	}
+	mgr = NewManager(WithLogDir(filepath.Join(blocker, "logs")), WithAutoCleanup(false),
+		WithWriteFallback(FallbackDiscard), WithErrorHandler(handler))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		mgr.Logger("api").Info("hello")
+		mgr.SetLevel(zapcore.DebugLevel)
+	}()
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("logging from ErrorHandler deadlocked")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reported) == 0 {
+		t.Fatal("expected open failures to be reported")
+	}
+}
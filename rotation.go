@@ -0,0 +1,121 @@
+package zlog
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// logNameRegex 匹配 <prefix><YYYY-MM-DD>[-HH[-MM]].log[.N][.gz] 形式的日志文件名。
+var logNameRegex = regexp.MustCompile(`^(.*?)(\d{4}-\d{2}-\d{2})(?:-(\d{2})(?:-(\d{2}))?)?\.log(?:\.(\d+))?(\.gz)?$`)
+
+// LogFile 描述 zlog 生成的日志文件名中解析出的信息。
+type LogFile struct {
+	Name       string        // 文件名（不含目录）
+	Prefix     string        // 时间戳之前的部分，例如 api_info、log_error
+	Time       time.Time     // 文件名中的时间戳（本地时区）
+	Precision  time.Duration // 时间戳精度：天、小时或分钟
+	Generation int           // rotatelogs 追加的 .N 序号，0 表示没有
+	Compressed bool          // 是否为 .gz 压缩文件
+}
+
+// Logger 返回文件所属的 logger 名称（最后一个下划线之前的部分），例如 order_api_info -> order_api。
+func (f LogFile) Logger() string {
+	if idx := strings.LastIndex(f.Prefix, "_"); idx > 0 {
+		return f.Prefix[:idx]
+	}
+	return f.Prefix
+}
+
+// Kind 返回文件种类（最后一个下划线之后的部分），例如 api_info -> info、api_error -> error。
+func (f LogFile) Kind() string {
+	if idx := strings.LastIndex(f.Prefix, "_"); idx > 0 {
+		return f.Prefix[idx+1:]
+	}
+	return ""
+}
+
+// rotationPeriod 返回实际生效的切割周期，RotationPeriod 优先于 WithRotationTime。
+func (cfg Config) rotationPeriod() time.Duration {
+	if cfg.RotationPeriod > 0 {
+		return cfg.RotationPeriod
+	}
+	if cfg.WithRotationTime > 0 {
+		return time.Duration(cfg.WithRotationTime) * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// rotationLayout 按切割周期选择文件名中的 strftime 时间模板。
+func rotationLayout(period time.Duration) string {
+	switch {
+	case period%(24*time.Hour) == 0:
+		return "%Y-%m-%d"
+	case period%time.Hour == 0:
+		return "%Y-%m-%d-%H"
+	default:
+		return "%Y-%m-%d-%H-%M"
+	}
+}
+
+// rotationPattern 根据链接文件名生成 rotatelogs 使用的文件名模板。
+func rotationPattern(fileName string, period time.Duration) string {
+	return strings.TrimSuffix(fileName, ".log") + rotationLayout(period) + ".log"
+}
+
+// ParseLogFileName 解析 <prefix><YYYY-MM-DD>[-HH[-MM]].log[.N][.gz] 形式的文件名，不匹配时返回 false。
+func ParseLogFileName(fileName string) (LogFile, bool) {
+	fileName = filepath.Base(fileName)
+	match := logNameRegex.FindStringSubmatch(fileName)
+	if match == nil {
+		return LogFile{}, false
+	}
+
+	day, err := time.ParseInLocation("2006-01-02", match[2], location)
+	if err != nil {
+		return LogFile{}, false
+	}
+
+	hour, minute := 0, 0
+	precision := 24 * time.Hour
+	if match[3] != "" {
+		hour, _ = strconv.Atoi(match[3])
+		precision = time.Hour
+	}
+	if match[4] != "" {
+		minute, _ = strconv.Atoi(match[4])
+		precision = time.Minute
+	}
+	if hour > 23 || minute > 59 {
+		return LogFile{}, false
+	}
+
+	generation := 0
+	if match[5] != "" {
+		generation, _ = strconv.Atoi(match[5])
+	}
+
+	return LogFile{
+		Name:       fileName,
+		Prefix:     match[1],
+		Time:       time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, location),
+		Precision:  precision,
+		Generation: generation,
+		Compressed: match[6] != "",
+	}, true
+}
+
+// truncateLocal 按本地时区将时间截断到指定精度（天、小时或分钟）。
+func truncateLocal(t time.Time, precision time.Duration) time.Time {
+	t = t.In(location)
+	switch {
+	case precision >= 24*time.Hour:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, location)
+	case precision >= time.Hour:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, location)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, location)
+	}
+}
@@ -0,0 +1,156 @@
+package zlog
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotationLayout(t *testing.T) {
+	cases := map[time.Duration]string{
+		24 * time.Hour:   "%Y-%m-%d",
+		48 * time.Hour:   "%Y-%m-%d",
+		time.Hour:        "%Y-%m-%d-%H",
+		6 * time.Hour:    "%Y-%m-%d-%H",
+		15 * time.Minute: "%Y-%m-%d-%H-%M",
+	}
+	for period, want := range cases {
+		if got := rotationLayout(period); got != want {
+			t.Fatalf("period %s expected layout %s, got %s", period, want, got)
+		}
+	}
+
+	patterns := map[string]string{
+		"/tmp/api_info.log":             "/tmp/api_info%Y-%m-%d-%H.log",
+		"/var/logs.log/audit.login.log": "/var/logs.log/audit.login%Y-%m-%d-%H.log",
+		"/tmp/catalog_info.log":         "/tmp/catalog_info%Y-%m-%d-%H.log",
+	}
+	for name, want := range patterns {
+		if got := rotationPattern(name, time.Hour); got != want {
+			t.Fatalf("%s: expected pattern %s, got %s", name, want, got)
+		}
+	}
+}
+
+func TestRotationPeriodOption(t *testing.T) {
+	cfg := newDefaultConfig()
+	if got := cfg.rotationPeriod(); got != 24*time.Hour {
+		t.Fatalf("expected default period 24h, got %s", got)
+	}
+
+	applyOptions(&cfg, WithRotationPeriod(15*time.Minute))
+	if got := cfg.rotationPeriod(); got != 15*time.Minute {
+		t.Fatalf("expected 15m period, got %s", got)
+	}
+
+	// WithRotationTime 按小时设置时覆盖之前的 RotationPeriod
+	applyOptions(&cfg, WithRotationTime(2))
+	if got := cfg.rotationPeriod(); got != 2*time.Hour {
+		t.Fatalf("expected 2h period, got %s", got)
+	}
+}
+
+func TestParseLogFileNameSubDaily(t *testing.T) {
+	cases := []struct {
+		name      string
+		prefix    string
+		want      time.Time
+		precision time.Duration
+	}{
+		{"api_info2025-01-15.log", "api_info", time.Date(2025, 1, 15, 0, 0, 0, 0, location), 24 * time.Hour},
+		{"api_info2025-01-15-13.log", "api_info", time.Date(2025, 1, 15, 13, 0, 0, 0, location), time.Hour},
+		{"api_info2025-01-15-13-45.log.2", "api_info", time.Date(2025, 1, 15, 13, 45, 0, 0, location), time.Minute},
+		{"log_error2025-01-15-00.log", "log_error", time.Date(2025, 1, 15, 0, 0, 0, 0, location), time.Hour},
+	}
+	for _, tc := range cases {
+		stamp, ok := ParseLogFileName(tc.name)
+		if !ok {
+			t.Fatalf("%s: expected match", tc.name)
+		}
+		if stamp.Prefix != tc.prefix || !stamp.Time.Equal(tc.want) || stamp.Precision != tc.precision {
+			t.Fatalf("%s: got prefix=%s time=%s precision=%s", tc.name, stamp.Prefix, stamp.Time, stamp.Precision)
+		}
+	}
+
+	for _, name := range []string{"api_info2025-01-15-25.log", "api_info2025-01-15-13-61.log", "api_info.log"} {
+		if _, ok := ParseLogFileName(name); ok {
+			t.Fatalf("%s: expected no match", name)
+		}
+	}
+}
+
+func TestParseLogFileNameGenerationAndCompression(t *testing.T) {
+	f, ok := ParseLogFileName("/var/log/app/order_api_info2025-01-15.log.3.gz")
+	if !ok {
+		t.Fatal("expected match")
+	}
+	if f.Name != "order_api_info2025-01-15.log.3.gz" || f.Generation != 3 || !f.Compressed {
+		t.Fatalf("unexpected parse result: %+v", f)
+	}
+	if f.Logger() != "order_api" || f.Kind() != "info" {
+		t.Fatalf("expected logger order_api / kind info, got %s / %s", f.Logger(), f.Kind())
+	}
+}
+
+func TestCleanupSubDailyRetention(t *testing.T) {
+	tmpDir := t.TempDir()
+	origDir := logDir()
+	setLogDir(tmpDir)
+	t.Cleanup(func() { setLogDir(origDir) })
+
+	now := time.Now().In(location)
+	hourly := func(offset time.Duration) string {
+		return filepath.Join(tmpDir, "api_info"+now.Add(offset).Format("2006-01-02-15")+".log")
+	}
+	current := hourly(0)
+	previous := hourly(-time.Hour)
+	expired := hourly(-3 * time.Hour)
+	for _, path := range []string{current, previous, expired} {
+		if err := os.WriteFile(path, []byte("{}\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 保留 1 小时：上一个小时的文件仍保留，3 小时前的文件被删除
+	clearLogWithConfig(&Config{WithMaxAge: 1, DefaultLoggerName: "api"})
+
+	for _, path := range []string{current, previous} {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("expected %s to be kept: %v", path, err)
+		}
+	}
+	if _, err := os.Stat(expired); !os.IsNotExist(err) {
+		t.Fatalf("expected %s to be removed, err=%v", expired, err)
+	}
+}
+
+func TestHourlyRotationFileName(t *testing.T) {
+	mgr, tmpDir := newTestManager(t, WithRotationPeriod(time.Hour))
+	mgr.Logger("hourly").Info("hourly rotation")
+	if err := mgr.Sync("hourly"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := filepath.Join(tmpDir, "hourly_info"+time.Now().In(location).Format("2006-01-02-15")+".log")
+	if _, err := os.Stat(want); err != nil {
+		t.Fatalf("expected hourly file %s: %v", want, err)
+	}
+}
+
+func TestSetZapOutDottedName(t *testing.T) {
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	mgr, tmpDir := newTestManager(t)
+	target := filepath.Join(tmpDir, "a.logger.log")
+	if err := mgr.SetZapOut(target); err != nil {
+		t.Fatalf("SetZapOut failed: %v", err)
+	}
+	log.Println("dotted name")
+
+	want := filepath.Join(tmpDir, "a.logger"+time.Now().In(location).Format("2006-01-02")+".log")
+	if _, err := os.Stat(want); err != nil {
+		t.Fatalf("expected %s: %v", want, err)
+	}
+}
@@ -0,0 +1,213 @@
+package zlog
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// schedule 计算后台清理的下一次执行时间。
+type schedule interface {
+	Next(after time.Time) time.Time
+}
+
+// intervalSchedule 按固定间隔执行。
+type intervalSchedule struct {
+	interval time.Duration
+}
+
+// Next 返回 after 之后一个间隔的时间。
+func (s intervalSchedule) Next(after time.Time) time.Time {
+	return after.Add(s.interval)
+}
+
+// dailySchedule 每天在固定的本地时间执行。
+type dailySchedule struct {
+	hour, minute int
+}
+
+// parseDailyAt 解析 "HH:MM" 格式的每日执行时间。
+func parseDailyAt(spec string) (dailySchedule, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(spec))
+	if err != nil {
+		return dailySchedule{}, fmt.Errorf("zlog: invalid cleanup time %q, want HH:MM", spec)
+	}
+	return dailySchedule{hour: t.Hour(), minute: t.Minute()}, nil
+}
+
+// Next 返回 after 之后最近一次的 HH:MM（本地时区）。
+func (s dailySchedule) Next(after time.Time) time.Time {
+	local := after.In(location)
+	next := time.Date(local.Year(), local.Month(), local.Day(), s.hour, s.minute, 0, 0, location)
+	if !next.After(local) {
+		next = time.Date(local.Year(), local.Month(), local.Day()+1, s.hour, s.minute, 0, 0, location)
+	}
+	return next
+}
+
+// cronSchedule 是标准 5 段 cron 表达式（分 时 日 月 周），按本地时区计算。
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+// cronDescriptors 是常用的 cron 简写。
+var cronDescriptors = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+}
+
+// parseCron 解析 5 段 cron 表达式，支持 *、列表（1,2）、区间（1-5）、步长（*/15、0-30/10）及 @daily 等简写。
+func parseCron(expr string) (*cronSchedule, error) {
+	spec := strings.TrimSpace(expr)
+	if d, ok := cronDescriptors[spec]; ok {
+		spec = d
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("zlog: invalid cron expression %q, want 5 fields", expr)
+	}
+
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	var sets [5]uint64
+	for i, field := range fields {
+		set, err := parseCronField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("zlog: invalid cron expression %q: %v", expr, err)
+		}
+		sets[i] = set
+	}
+	// 周字段 7 与 0 都表示周日
+	if sets[4]&(1<<7) != 0 {
+		sets[4] = sets[4]&^(1<<7) | 1
+	}
+
+	return &cronSchedule{
+		minute: sets[0],
+		hour:   sets[1],
+		dom:    sets[2],
+		month:  sets[3],
+		dow:    sets[4],
+		domAny: fields[2] == "*",
+		dowAny: fields[4] == "*",
+	}, nil
+}
+
+// parseCronField 将单个 cron 字段解析为位集合。
+func parseCronField(field string, min, max int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			n, err := strconv.Atoi(part[idx+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("bad step in %q", part)
+			}
+			rangePart, step = part[:idx], n
+		}
+
+		lo, hi := min, max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(bounds[0])
+			hi, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("bad range %q", part)
+			}
+		default:
+			n, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, fmt.Errorf("bad value %q", part)
+			}
+			lo, hi = n, n
+			if step > 1 {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("value out of range [%d,%d] in %q", min, max, part)
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+// Next 返回 after 之后第一个匹配表达式的整分钟时间；五年内无匹配时返回零值。
+func (s *cronSchedule) Next(after time.Time) time.Time {
+	t := after.In(location).Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, location)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, location)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, location)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches 按 cron 语义判断日期：日与周同时受限时满足其一即可。
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	domOK := s.dom&(1<<uint(t.Day())) != 0
+	dowOK := s.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dowOK
+	case s.dowAny:
+		return domOK
+	default:
+		return domOK || dowOK
+	}
+}
+
+// cleanupSchedule 根据配置返回清理计划，优先级：CleanupCron > CleanupAt > CleanupInterval。
+func (cfg Config) cleanupSchedule() (schedule, error) {
+	switch {
+	case strings.TrimSpace(cfg.CleanupCron) != "":
+		return parseCron(cfg.CleanupCron)
+	case strings.TrimSpace(cfg.CleanupAt) != "":
+		return parseDailyAt(cfg.CleanupAt)
+	case cfg.CleanupInterval <= 0:
+		return intervalSchedule{interval: defaultCleanupInterval}, nil
+	default:
+		return intervalSchedule{interval: cfg.CleanupInterval}, nil
+	}
+}
+
+// cleanupScheduleKey 返回决定清理任务行为的配置摘要，变化时需要重启任务。
+func (cfg Config) cleanupScheduleKey() string {
+	return fmt.Sprintf("%s|%s|%s|%s|%t", cfg.CleanupInterval, cfg.CleanupAt, cfg.CleanupCron, cfg.CleanupJitter, cfg.CleanupOnStart)
+}
+
+// withJitter 在 t 上叠加 [0, jitter) 的随机延迟。
+func withJitter(t time.Time, jitter time.Duration) time.Time {
+	if jitter <= 0 {
+		return t
+	}
+	return t.Add(time.Duration(rand.Int63n(int64(jitter))))
+}
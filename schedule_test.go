@@ -0,0 +1,151 @@
+package zlog
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestDailySchedule 测试每日定时计划
+func TestDailySchedule(t *testing.T) {
+	s, err := parseDailyAt("03:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{time.Date(2025, 1, 15, 1, 0, 0, 0, location), time.Date(2025, 1, 15, 3, 0, 0, 0, location)},
+		{time.Date(2025, 1, 15, 3, 0, 0, 0, location), time.Date(2025, 1, 16, 3, 0, 0, 0, location)},
+		{time.Date(2025, 1, 31, 23, 59, 0, 0, location), time.Date(2025, 2, 1, 3, 0, 0, 0, location)},
+	}
+	for _, c := range cases {
+		if got := s.Next(c.now); !got.Equal(c.want) {
+			t.Fatalf("Next(%s) = %s, want %s", c.now, got, c.want)
+		}
+	}
+
+	if _, err := parseDailyAt("25:00"); err == nil {
+		t.Fatal("expected error for invalid time")
+	}
+}
+
+// TestCronSchedule 测试 cron 表达式解析与下次执行时间
+func TestCronSchedule(t *testing.T) {
+	// 2025-01-15 是周三
+	now := time.Date(2025, 1, 15, 10, 7, 30, 0, location)
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2025, 1, 15, 10, 15, 0, 0, location)},
+		{"30 3 * * *", time.Date(2025, 1, 16, 3, 30, 0, 0, location)},
+		{"@daily", time.Date(2025, 1, 16, 0, 0, 0, 0, location)},
+		{"0 4 * * 6,0", time.Date(2025, 1, 18, 4, 0, 0, 0, location)},
+		{"0 4 * * 7", time.Date(2025, 1, 19, 4, 0, 0, 0, location)},
+		{"0 0 1 */3 *", time.Date(2025, 4, 1, 0, 0, 0, 0, location)},
+		{"0 12 20 * 1", time.Date(2025, 1, 20, 12, 0, 0, 0, location)}, // 日与周满足其一
+		{"5-10/5 10 * * *", time.Date(2025, 1, 15, 10, 10, 0, 0, location)},
+	}
+	for _, c := range cases {
+		s, err := parseCron(c.expr)
+		if err != nil {
+			t.Fatalf("parseCron(%q): %v", c.expr, err)
+		}
+		if got := s.Next(now); !got.Equal(c.want) {
+			t.Fatalf("%q: Next = %s, want %s", c.expr, got, c.want)
+		}
+	}
+
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "*/0 * * * *", "a * * * *", "5-1 * * * *"} {
+		if _, err := parseCron(expr); err == nil {
+			t.Fatalf("expected error for %q", expr)
+		}
+	}
+}
+
+// TestCleanupJitter 测试抖动范围
+func TestCleanupJitter(t *testing.T) {
+	cfg := newDefaultConfig()
+	cfg.CleanupAt = "03:00"
+	cfg.CleanupJitter = 10 * time.Minute
+	task, err := newScheduledCleanupTask(cfg, func() {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2025, 1, 15, 1, 0, 0, 0, location)
+	base := time.Date(2025, 1, 15, 3, 0, 0, 0, location)
+	for i := 0; i < 100; i++ {
+		next := task.nextRun(now)
+		if next.Before(base) || !next.Before(base.Add(cfg.CleanupJitter)) {
+			t.Fatalf("next run %s outside jitter window", next)
+		}
+	}
+
+	cfg.CleanupCron = "bad"
+	task, err = newScheduledCleanupTask(cfg, func() {})
+	if err == nil {
+		t.Fatal("expected error for invalid cron")
+	}
+	if _, ok := task.schedule.(intervalSchedule); !ok {
+		t.Fatalf("invalid cron should fall back to interval, got %T", task.schedule)
+	}
+}
+
+// TestCleanupOnStart 测试启动时立即清理
+func TestCleanupOnStart(t *testing.T) {
+	tmpDir := t.TempDir()
+	origDir := logDir()
+	t.Cleanup(func() { setLogDir(origDir) })
+
+	expired := filepath.Join(tmpDir, "svc_info2020-01-01.log")
+	if err := os.WriteFile(expired, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mgr := NewManager(
+		WithLogDir(tmpDir),
+		WithMaxAge(24),
+		WithCleanupAt("03:00"),
+		WithCleanupOnStart(true),
+		WithDefaultName("svc"),
+	)
+	t.Cleanup(mgr.StopCleanupTask)
+
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(expired); os.IsNotExist(err) {
+			t.Log("启动时已完成清理")
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatal("expected cleanup to run at start")
+}
+
+// TestCleanupIntervalNotPositive 测试间隔不大于 0 时退回默认间隔，而不是忙循环执行清理
+func TestCleanupIntervalNotPositive(t *testing.T) {
+	var runs atomic.Int64
+	mgr, _ := newTestManager(t,
+		WithAutoCleanup(true),
+		WithCleanupInterval(0),
+		WithCleanupCallback(func(CleanupReport) { runs.Add(1) }),
+	)
+	t.Cleanup(mgr.StopCleanupTask)
+	time.Sleep(300 * time.Millisecond)
+	if n := runs.Load(); n != 0 {
+		t.Fatalf("cleanup ran %d times with a zero interval", n)
+	}
+
+	now := time.Now()
+	for _, interval := range []time.Duration{0, -time.Minute} {
+		if next := newCleanupTask(interval, func() {}).nextRun(now); !next.Equal(now.Add(defaultCleanupInterval)) {
+			t.Fatalf("interval %s: expected next run in %s, got %s", interval, defaultCleanupInterval, next.Sub(now))
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package zlog
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// DefaultSignalLevelTTL 是收到切换等级信号后临时等级的默认有效期。
+const DefaultSignalLevelTTL = time.Hour
+
+// SignalOptions 配置 HandleSignals。
+type SignalOptions struct {
+	ConfigPath string        // 重新打开文件时重新读取的配置文件，为空时使用最近一次 ApplyConfigFile/WatchConfigFile 的路径
+	LevelTTL   time.Duration // 切换后的等级在此时长后自动恢复，0 表示 DefaultSignalLevelTTL
+	OnError    func(error)   // 重新读取配置失败时回调，为空时打印到 stderr
+}
+
+// signalSet 是 HandleSignals 监听的信号，由各平台提供。
+type signalSet struct {
+	cycle   os.Signal // 等级调低一级（输出更详细）
+	restore os.Signal // 恢复配置的等级
+	reopen  os.Signal // 重新打开日志文件并重新读取配置文件
+}
+
+// HandleSignals 监听信号以便在没有管理端口时调整日志（仅类 Unix 系统）：
+// SIGUSR1 将全局等级调低一级（如 Info -> Debug），已是 Debug 时恢复配置的等级；
+// SIGUSR2 恢复配置的等级；SIGHUP 重新打开全部日志文件并重新读取配置文件。
+// 切换的等级与 SetLevelFor 相同，在 LevelTTL 后自动恢复。返回的函数用于停止监听。
+func (m *Manager) HandleSignals(opts SignalOptions) (stop func(), err error) {
+	set, err := platformSignals()
+	if err != nil {
+		return nil, err
+	}
+	if opts.LevelTTL <= 0 {
+		opts.LevelTTL = DefaultSignalLevelTTL
+	}
+
+	ch := make(chan os.Signal, 4)
+	signal.Notify(ch, set.cycle, set.restore, set.reopen)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case sig := <-ch:
+				m.handleSignal(set, sig, opts)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}, nil
+}
+
+// handleSignal 执行单个信号对应的操作。
+func (m *Manager) handleSignal(set signalSet, sig os.Signal, opts SignalOptions) {
+	switch sig {
+	case set.cycle:
+		if level := m.level.Level(); level > zapcore.DebugLevel {
+			m.SetLevelFor(level-1, opts.LevelTTL)
+		} else {
+			m.CancelLevelFor("")
+		}
+	case set.restore:
+		m.CancelLevelFor("")
+	case set.reopen:
+		if err := m.reopen(opts.ConfigPath); err != nil {
+			if opts.OnError != nil {
+				opts.OnError(err)
+				return
+			}
+			fmt.Fprintf(os.Stderr, "%v, keeping the current config\n", err)
+		}
+	}
+}
+
+// reopen 重新读取配置文件（有路径时）并重新打开全部日志文件，配置无效时仍会重新打开文件。
+func (m *Manager) reopen(path string) error {
+	if path == "" {
+		m.cfgMu.RLock()
+		path = m.configPath
+		m.cfgMu.RUnlock()
+	}
+	if path != "" {
+		// 应用配置会重建全部 core，同时重新打开文件
+		if err := m.ApplyConfigFile(path); err != nil {
+			m.registry.reset()
+			return err
+		}
+		return nil
+	}
+	m.registry.reset()
+	return nil
+}
+
+// HandleSignals 为全局实例监听信号。
+func HandleSignals(opts SignalOptions) (stop func(), err error) {
+	return getDefaultManager().HandleSignals(opts)
+}
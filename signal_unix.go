@@ -0,0 +1,11 @@
+//go:build !windows
+// +build !windows
+
+package zlog
+
+import "syscall"
+
+// platformSignals 返回 HandleSignals 使用的信号。
+func platformSignals() (signalSet, error) {
+	return signalSet{cycle: syscall.SIGUSR1, restore: syscall.SIGUSR2, reopen: syscall.SIGHUP}, nil
+}
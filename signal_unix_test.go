@@ -0,0 +1,74 @@
+//go:build !windows
+// +build !windows
+
+package zlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// TestHandleSignals 测试向当前进程发送信号切换等级、恢复等级并重新打开文件
+func TestHandleSignals(t *testing.T) {
+	mgr, tmpDir := newTestManager(t)
+	cfgPath := filepath.Join(t.TempDir(), "zlog.yaml")
+	writeFile(t, cfgPath, "level: info\n")
+
+	if err := mgr.ApplyConfigFile(cfgPath); err != nil {
+		t.Fatalf("ApplyConfigFile: %v", err)
+	}
+	var reloadErrs []error
+	stop, err := mgr.HandleSignals(SignalOptions{LevelTTL: time.Minute, OnError: func(err error) { reloadErrs = append(reloadErrs, err) }})
+	if err != nil {
+		t.Fatalf("HandleSignals: %v", err)
+	}
+	t.Cleanup(stop)
+	send := func(sig syscall.Signal) {
+		if err := syscall.Kill(os.Getpid(), sig); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Log("SIGUSR1 将等级调低一级，并在 Stats 中可见")
+	send(syscall.SIGUSR1)
+	waitFor(t, func() bool { return mgr.level.Level() == zapcore.DebugLevel })
+	if list := mgr.Stats().Escalations; len(list) != 1 || list[0].Logger != "" {
+		t.Fatalf("expected a global escalation, got %+v", list)
+	}
+
+	t.Log("SIGUSR2 恢复配置的等级")
+	send(syscall.SIGUSR2)
+	waitFor(t, func() bool { return mgr.level.Level() == zapcore.InfoLevel })
+
+	t.Log("SIGHUP 重新读取配置文件并重新打开被移走的日志文件")
+	logger := mgr.Logger("api")
+	logger.Info("before reopen")
+	_ = mgr.Sync("api")
+	matches, _ := filepath.Glob(filepath.Join(tmpDir, "api_info2*.log"))
+	if len(matches) != 1 {
+		t.Fatalf("expected one dated log file, got %v", matches)
+	}
+	if err := os.Rename(matches[0], matches[0]+".1"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, cfgPath, "level: warn\n")
+	send(syscall.SIGHUP)
+	waitFor(t, func() bool { return mgr.getConfig().Level == zapcore.WarnLevel })
+
+	logger.Info("filtered after reload")
+	logger.Warn("after reopen")
+	_ = mgr.Sync("api")
+	content := readTodayLog(t, tmpDir, "api_info")
+	if !strings.Contains(content, "after reopen") || strings.Contains(content, "before reopen") || strings.Contains(content, "filtered") {
+		t.Fatalf("unexpected content after SIGHUP: %q", content)
+	}
+	if len(reloadErrs) != 0 {
+		t.Fatalf("unexpected reload errors: %v", reloadErrs)
+	}
+}
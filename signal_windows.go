@@ -0,0 +1,11 @@
+//go:build windows
+// +build windows
+
+package zlog
+
+import "errors"
+
+// platformSignals 在 Windows 上不可用：没有 SIGUSR1/SIGUSR2，也无法向进程发送 SIGHUP。
+func platformSignals() (signalSet, error) {
+	return signalSet{}, errors.New("zlog: HandleSignals is not supported on windows")
+}
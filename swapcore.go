@@ -0,0 +1,98 @@
+package zlog
+
+import (
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// swapState 是 swapCore 当前使用的 core 及其版本号。
+type swapState struct {
+	core zapcore.Core
+	gen  uint64
+}
+
+// swapCore 持有可原子替换的底层 core。注册表在重新配置时替换其中的 core，
+// 调用方保存的 logger（包括 With 派生出的 logger）在下一条日志时即使用新配置。
+type swapCore struct {
+	root   *swapRoot
+	fields []zapcore.Field
+	cache  atomic.Value // *swapState：带 fields 的派生 core
+}
+
+// swapRoot 是同名 logger 共享的替换点。
+type swapRoot struct {
+	state   atomic.Value // *swapState
+	lastUse atomic.Int64 // 最近一次写日志的时间（UnixNano），用于淘汰空闲 logger
+}
+
+// newSwapCore 创建指向 core 的可替换 core。
+func newSwapCore(core zapcore.Core) *swapCore {
+	root := &swapRoot{}
+	root.state.Store(&swapState{core: core})
+	return &swapCore{root: root}
+}
+
+// swap 替换底层 core，返回被替换的旧 core。
+func (c *swapCore) swap(core zapcore.Core) zapcore.Core {
+	old := c.root.state.Load().(*swapState)
+	c.root.state.Store(&swapState{core: core, gen: old.gen + 1})
+	return old.core
+}
+
+// inner 返回当前替换进来的 core（不含 With 附加的 fields）。
+func (c *swapCore) inner() zapcore.Core {
+	return c.root.state.Load().(*swapState).core
+}
+
+// touch 记录最近一次使用时间。
+func (c *swapCore) touch(t time.Time) {
+	c.root.lastUse.Store(t.UnixNano())
+}
+
+// current 返回当前生效的 core，带 fields 时按版本缓存派生结果。
+func (c *swapCore) current() zapcore.Core {
+	st := c.root.state.Load().(*swapState)
+	if len(c.fields) == 0 {
+		return st.core
+	}
+	if cached, ok := c.cache.Load().(*swapState); ok && cached.gen == st.gen {
+		return cached.core
+	}
+	derived := st.core.With(c.fields)
+	c.cache.Store(&swapState{core: derived, gen: st.gen})
+	return derived
+}
+
+// Enabled 实现 zapcore.LevelEnabler。
+func (c *swapCore) Enabled(lvl zapcore.Level) bool {
+	return c.current().Enabled(lvl)
+}
+
+// With 返回共享同一替换点、附加了 fields 的 core。
+func (c *swapCore) With(fields []zapcore.Field) zapcore.Core {
+	merged := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	merged = append(merged, c.fields...)
+	merged = append(merged, fields...)
+	return &swapCore{root: c.root, fields: merged}
+}
+
+// Check 交给当前 core 判断，写入时直接使用当前 core。
+func (c *swapCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	checked := c.current().Check(ent, ce)
+	if checked != ce {
+		c.touch(ent.Time)
+	}
+	return checked
+}
+
+// Write 写入当前 core。
+func (c *swapCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.current().Write(ent, fields)
+}
+
+// Sync 刷新当前 core。
+func (c *swapCore) Sync() error {
+	return c.current().Sync()
+}
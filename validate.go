@@ -0,0 +1,127 @@
+package zlog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// ConfigError 列出配置中的全部无效字段。
+type ConfigError struct {
+	Problems []string
+}
+
+// Error 实现 error 接口。
+func (e *ConfigError) Error() string {
+	return "zlog: invalid config: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate 检查配置中的全部字段，有问题时返回 *ConfigError。
+func (cfg Config) Validate() error {
+	if problems := cfg.problems(); len(problems) > 0 {
+		return &ConfigError{Problems: problems}
+	}
+	return nil
+}
+
+// problems 返回配置中每个无效字段的说明。
+func (cfg Config) problems() []string {
+	var problems []string
+	problem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	negative := func(name string, v int64) {
+		if v < 0 {
+			problem("%s must not be negative, got %d", name, v)
+		}
+	}
+
+	if !knownEnv(cfg.Env) {
+		problem("unknown env %q", cfg.Env)
+	}
+	if cfg.Level < zapcore.DebugLevel || cfg.Level > zapcore.FatalLevel {
+		problem("unknown level %d", cfg.Level)
+	}
+	if cfg.formDate == "" {
+		problem("date format must not be empty")
+	}
+	if strings.TrimSpace(cfg.LogDir) == "" {
+		problem("log dir must not be empty")
+	}
+	maxLen := cfg.maxLoggerNameLength()
+	if err := ValidateLoggerName(cfg.DefaultLoggerName, maxLen); err != nil {
+		problem("default logger name: %v", err)
+	}
+	if err := ValidateLoggerName(cfg.ErrorLoggerName, maxLen); err != nil {
+		problem("error logger name: %v", err)
+	}
+
+	negative("max age", int64(cfg.WithMaxAge))
+	negative("rotation time", int64(cfg.WithRotationTime))
+	if cfg.RotationPeriod < 0 || (cfg.RotationPeriod > 0 && cfg.RotationPeriod < time.Minute) {
+		problem("rotation period must be at least 1m, got %s", cfg.RotationPeriod)
+	}
+	negative("max files", int64(cfg.MaxFiles))
+	negative("max loggers", int64(cfg.MaxLoggers))
+	negative("logger idle timeout", int64(cfg.LoggerIdleTimeout))
+	negative("max logger name length", int64(cfg.MaxLoggerNameLength))
+	negative("fallback buffer size", int64(cfg.FallbackBufferSize))
+	negative("emergency free bytes", cfg.EmergencyFreeBytes)
+	negative("reopen backoff", int64(cfg.ReopenBackoff))
+	negative("reopen max backoff", int64(cfg.ReopenMaxBackoff))
+	if s := cfg.Sampling; s != nil && (s.Tick < 0 || s.Initial < 0 || s.Thereafter < 0) {
+		problem("sampling values must not be negative, got %+v", *s)
+	}
+	if cfg.WriteFallback < FallbackStderr || cfg.WriteFallback > FallbackBuffer {
+		problem("unknown write fallback %d", cfg.WriteFallback)
+	}
+
+	for _, lf := range cfg.LevelFiles {
+		if err := ValidateLoggerName(lf.Name, maxLen); err != nil {
+			problem("level file: %v", err)
+		}
+		if lf.Min > lf.Max {
+			problem("level file %q: min %s is above max %s", lf.Name, lf.Min, lf.Max)
+		}
+	}
+	for name, level := range cfg.LoggerLevels {
+		if err := ValidateLoggerName(name, maxLen); err != nil {
+			problem("logger level: %v", err)
+		} else if level < zapcore.DebugLevel || level > zapcore.FatalLevel {
+			problem("logger level %q: unknown level %d", name, level)
+		}
+	}
+	for name := range cfg.SharedFiles {
+		if err := ValidateLoggerName(name, maxLen); err != nil {
+			problem("shared file: %v", err)
+		}
+	}
+	for name := range cfg.LoggerErrorFiles {
+		if err := ValidateLoggerName(name, maxLen); err != nil {
+			problem("logger error file: %v", err)
+		}
+	}
+
+	if cfg.AutoCleanup && cfg.CleanupCron == "" && cfg.CleanupAt == "" && cfg.CleanupInterval <= 0 {
+		problem("cleanup interval must be positive, got %s", cfg.CleanupInterval)
+	}
+	if cfg.CleanupAt != "" {
+		if _, err := parseDailyAt(cfg.CleanupAt); err != nil {
+			problem("%v", err)
+		}
+	}
+	if cfg.CleanupCron != "" {
+		if _, err := parseCron(cfg.CleanupCron); err != nil {
+			problem("%v", err)
+		}
+	}
+	negative("cleanup jitter", int64(cfg.CleanupJitter))
+	if cfg.CleanupLogName != "" {
+		if err := ValidateLoggerName(cfg.CleanupLogName, maxLen); err != nil {
+			problem("cleanup logger name: %v", err)
+		}
+	}
+	return problems
+}
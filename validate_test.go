@@ -0,0 +1,115 @@
+package zlog
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// TestConfigValidate 测试 Validate 报告全部无效字段
+func TestConfigValidate(t *testing.T) {
+	cfg := builtinConfig()
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("default config should be valid: %v", err)
+	}
+
+	applyOptions(&cfg,
+		WithMaxAge(-1),
+		WithCleanupInterval(0),
+		WithMaxFiles(-2),
+		WithRotationPeriod(time.Second),
+		WithLevelFiles(LevelFile{Name: "bad", Min: zapcore.ErrorLevel, Max: zapcore.InfoLevel}),
+		WithLoggerLevel("../x", zapcore.DebugLevel),
+	)
+	cfg.Env = "staging"
+
+	err := cfg.Validate()
+	var cfgErr *ConfigError
+	if !errors.As(err, &cfgErr) {
+		t.Fatalf("expected *ConfigError, got %v", err)
+	}
+	want := []string{"unknown env", "max age", "rotation period", "max files", `level file "bad"`, "logger level", "cleanup interval"}
+	if len(cfgErr.Problems) != len(want) {
+		t.Fatalf("expected %d problems, got %q", len(want), cfgErr.Problems)
+	}
+	for _, w := range want {
+		if !strings.Contains(err.Error(), w) {
+			t.Fatalf("missing %q in %v", w, err)
+		}
+	}
+
+	t.Log("清理时间与 cron 表达式同样校验")
+	cfg = builtinConfig()
+	applyOptions(&cfg, WithCleanupCron("61 * * * *"))
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "cron") {
+		t.Fatalf("expected cron error, got %v", err)
+	}
+	cfg = builtinConfig()
+	applyOptions(&cfg, WithCleanupAt("25:00"))
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "cleanup time") {
+		t.Fatalf("expected cleanup time error, got %v", err)
+	}
+}
+
+// TestNewManagerE 测试 NewManagerE 拒绝无效配置与无法创建的日志目录
+func TestNewManagerE(t *testing.T) {
+	tmpDir := t.TempDir()
+	origDir := logDir()
+	t.Cleanup(func() { setLogDir(origDir) })
+
+	if _, err := NewManagerE(WithLogDir(tmpDir), WithMaxAge(-1)); err == nil {
+		t.Fatal("expected error for negative max age")
+	}
+
+	blocker := filepath.Join(tmpDir, "file")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewManagerE(WithLogDir(filepath.Join(blocker, "logs")), WithAutoCleanup(false)); err == nil || !strings.Contains(err.Error(), "log dir") {
+		t.Fatalf("expected log dir error, got %v", err)
+	}
+
+	t.Setenv(envVarConsoleOnly, "maybe")
+	if _, err := NewManagerE(WithLogDir(tmpDir), WithAutoCleanup(false)); err == nil || !strings.Contains(err.Error(), envVarConsoleOnly) {
+		t.Fatalf("expected env var error, got %v", err)
+	}
+	t.Setenv(envVarConsoleOnly, "")
+
+	mgr, err := NewManagerE(WithLogDir(tmpDir), WithAutoCleanup(false))
+	if err != nil {
+		t.Fatalf("NewManagerE: %v", err)
+	}
+	mgr.Logger("api").Info("ok")
+	if content := readTodayLog(t, tmpDir, "api_info"); !strings.Contains(content, "ok") {
+		t.Fatalf("unexpected content: %q", content)
+	}
+}
+
+// TestSetLogE 测试 SetLogE 配置无效时不做任何修改，SetLog 保持原有行为
+func TestSetLogE(t *testing.T) {
+	mgr, _ := newTestManager(t)
+	before := mgr.getConfig()
+	if err := mgr.SetLogE(Env("staging"), WithConsoleOnly(true)); err == nil {
+		t.Fatal("expected error for unknown env")
+	}
+	if after := mgr.getConfig(); after.Env != before.Env || after.ConsoleOnly {
+		t.Fatal("SetLogE must not apply an invalid config")
+	}
+
+	if err := mgr.SetLogE(ENV_WARN, WithMaxFiles(3)); err != nil {
+		t.Fatalf("SetLogE: %v", err)
+	}
+	if cfg := mgr.getConfig(); cfg.Level != zapcore.WarnLevel || cfg.MaxFiles != 3 {
+		t.Fatalf("valid config not applied: %+v", cfg)
+	}
+
+	mgr.SetLog(Env("staging"))
+	if mgr.getConfig().Env != Env("staging") {
+		t.Fatal("SetLog should keep accepting unknown env strings")
+	}
+}
@@ -0,0 +1,70 @@
+package zlog
+
+import (
+	"os"
+	"sync"
+	"sync/atomic"
+
+	rotatelogs "github.com/lestrrat-go/file-rotatelogs"
+)
+
+// rotateWriter 包装 rotatelogs.RotateLogs，统计切割次数并提供当前文件信息。
+type rotateWriter struct {
+	*rotatelogs.RotateLogs
+	rotations atomic.Uint64
+
+	mu       sync.Mutex
+	onRotate func()
+}
+
+// newRotateWriter 创建带切割回调的滚动写入器。
+func newRotateWriter(pattern string, options ...rotatelogs.Option) (*rotateWriter, error) {
+	w := &rotateWriter{}
+	rl, err := rotatelogs.New(pattern, append(options, rotatelogs.WithHandler(w))...)
+	if err != nil {
+		return nil, err
+	}
+	w.RotateLogs = rl
+	return w, nil
+}
+
+// Handle 实现 rotatelogs.Handler，首次打开文件不计为切割。
+func (w *rotateWriter) Handle(e rotatelogs.Event) {
+	ev, ok := e.(*rotatelogs.FileRotatedEvent)
+	if !ok || ev.PreviousFile() == "" {
+		return
+	}
+	w.rotations.Add(1)
+
+	w.mu.Lock()
+	fn := w.onRotate
+	w.mu.Unlock()
+	if fn != nil {
+		fn()
+	}
+}
+
+// setRotateHook 设置每次切割后的回调。
+func (w *rotateWriter) setRotateHook(fn func()) {
+	w.mu.Lock()
+	w.onRotate = fn
+	w.mu.Unlock()
+}
+
+// Sync 实现 zapcore.WriteSyncer，rotatelogs 直接写文件无需额外刷新。
+func (w *rotateWriter) Sync() error {
+	return nil
+}
+
+// fileSize 返回当前正在写入文件的大小，尚未写入时返回 0。
+func (w *rotateWriter) fileSize() int64 {
+	name := w.CurrentFileName()
+	if name == "" {
+		return 0
+	}
+	info, err := os.Stat(name)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
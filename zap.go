@@ -1,55 +1,131 @@
 package zlog
 
-func Info(args ...interface{}) {
-	F("zlog", "").Info(args...)
+import "go.uber.org/zap"
+
+// topLevelLogger 是顶层快捷函数固定使用的通道，日志写入 logs/zlog_info.log。
+const topLevelLogger = "zlog"
+
+// std 返回顶层快捷函数使用的 logger，第二个参数让调用位置指向业务代码。
+func std() *zap.SugaredLogger {
+	return F(topLevelLogger, "")
 }
 
-func Error(args ...interface{}) {
-	F("zlog", "").Error(args...)
+// L 返回指定名称的 *zap.Logger，用于零分配的强类型结构化日志，参数含义与 F 相同。
+func L(fileNameArr ...string) *zap.Logger {
+	return getDefaultManager().ZapLogger(fileNameArr...)
 }
 
 // Debug uses fmt.Sprint to construct and log a message.
 func Debug(args ...interface{}) {
-	F("zlog", "").Debug(args...)
+	std().Debug(args...)
+}
+
+// Info uses fmt.Sprint to construct and log a message.
+func Info(args ...interface{}) {
+	std().Info(args...)
 }
 
 // Warn uses fmt.Sprint to construct and log a message.
 func Warn(args ...interface{}) {
-	F("zlog", "").Debug(args...)
+	std().Warn(args...)
+}
+
+// Error uses fmt.Sprint to construct and log a message.
+func Error(args ...interface{}) {
+	std().Error(args...)
+}
+
+// DPanic uses fmt.Sprint to construct and log a message. In development, the
+// logger then panics.
+func DPanic(args ...interface{}) {
+	std().DPanic(args...)
 }
 
 // Panic uses fmt.Sprint to construct and log a message, then panics.
 func Panic(args ...interface{}) {
-	F("zlog", "").Panic(args...)
+	std().Panic(args...)
 }
 
 // Fatal uses fmt.Sprint to construct and log a message, then calls os.Exit.
 func Fatal(args ...interface{}) {
-	F("zlog", "").Fatal(args...)
+	std().Fatal(args...)
 }
 
+// Debugf uses fmt.Sprintf to log a templated message.
+func Debugf(template string, args ...interface{}) {
+	std().Debugf(template, args...)
+}
+
+// Infof uses fmt.Sprintf to log a templated message.
 func Infof(template string, args ...interface{}) {
-	F("zlog", "").Infof(template, args...)
+	std().Infof(template, args...)
 }
 
 // Warnf uses fmt.Sprintf to log a templated message.
 func Warnf(template string, args ...interface{}) {
-	F("zlog", "").Warnf(template, args...)
+	std().Warnf(template, args...)
 }
 
 // Errorf uses fmt.Sprintf to log a templated message.
 func Errorf(template string, args ...interface{}) {
-	F("zlog", "").Errorf(template, args...)
+	std().Errorf(template, args...)
+}
 
+// DPanicf uses fmt.Sprintf to log a templated message. In development, the
+// logger then panics.
+func DPanicf(template string, args ...interface{}) {
+	std().DPanicf(template, args...)
 }
 
 // Panicf uses fmt.Sprintf to log a templated message, then panics.
 func Panicf(template string, args ...interface{}) {
-	F("zlog", "").Panicf(template, args...)
-
+	std().Panicf(template, args...)
 }
 
 // Fatalf uses fmt.Sprintf to log a templated message, then calls os.Exit.
 func Fatalf(template string, args ...interface{}) {
-	F("zlog", "").Fatalf(template, args...)
+	std().Fatalf(template, args...)
+}
+
+// Debugw logs a message with some additional context. The variadic key-value
+// pairs are treated as they are in With.
+func Debugw(msg string, keysAndValues ...interface{}) {
+	std().Debugw(msg, keysAndValues...)
+}
+
+// Infow logs a message with some additional context. The variadic key-value
+// pairs are treated as they are in With.
+func Infow(msg string, keysAndValues ...interface{}) {
+	std().Infow(msg, keysAndValues...)
+}
+
+// Warnw logs a message with some additional context. The variadic key-value
+// pairs are treated as they are in With.
+func Warnw(msg string, keysAndValues ...interface{}) {
+	std().Warnw(msg, keysAndValues...)
+}
+
+// Errorw logs a message with some additional context. The variadic key-value
+// pairs are treated as they are in With.
+func Errorw(msg string, keysAndValues ...interface{}) {
+	std().Errorw(msg, keysAndValues...)
+}
+
+// DPanicw logs a message with some additional context. In development, the
+// logger then panics. The variadic key-value pairs are treated as they are in
+// With.
+func DPanicw(msg string, keysAndValues ...interface{}) {
+	std().DPanicw(msg, keysAndValues...)
+}
+
+// Panicw logs a message with some additional context, then panics. The
+// variadic key-value pairs are treated as they are in With.
+func Panicw(msg string, keysAndValues ...interface{}) {
+	std().Panicw(msg, keysAndValues...)
+}
+
+// Fatalw logs a message with some additional context, then calls os.Exit. The
+// variadic key-value pairs are treated as they are in With.
+func Fatalw(msg string, keysAndValues ...interface{}) {
+	std().Fatalw(msg, keysAndValues...)
 }
@@ -0,0 +1,57 @@
+package zlog
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TestTopLevelHelpers 测试顶层快捷函数的等级、字段与调用位置
+func TestTopLevelHelpers(t *testing.T) {
+	orig := getConfig()
+	t.Cleanup(func() {
+		SetLog(orig.Env, WithLevel(orig.Level), WithLogDir(orig.LogDir), WithAutoCleanup(orig.AutoCleanup))
+	})
+
+	tmpDir := t.TempDir()
+	SetLog(ENV_INFO, WithLogDir(tmpDir), WithLevel(zapcore.DebugLevel), WithAutoCleanup(false))
+
+	_, file, line, _ := runtime.Caller(0)
+	Warn("warn message")
+	Debugf("debug %d", 1)
+	Infow("kv message", "order_id", 42)
+	Errorw("error kv", "code", "E1")
+	DPanicf("dpanic %s", "not in development")
+	L("typed").Info("typed message", zap.Int("n", 7))
+	_ = Sync(topLevelLogger)
+	_ = Sync("typed")
+
+	content := readTodayLog(t, tmpDir, "zlog_info")
+	for _, want := range []string{
+		`"level":"warn","time"`,
+		`"message":"warn message"`,
+		`"message":"debug 1"`,
+		`"message":"kv message","order_id":42`,
+		`"message":"error kv","code":"E1"`,
+		`"level":"dpanic"`,
+	} {
+		if !strings.Contains(content, want) {
+			t.Fatalf("log missing %s:\n%s", want, content)
+		}
+	}
+
+	if typed := readTodayLog(t, tmpDir, "typed_info"); !strings.Contains(typed, `"message":"typed message","n":7`) || !strings.Contains(typed, "zap_test.go") {
+		t.Fatalf("unexpected typed log:\n%s", typed)
+	}
+
+	// 顶层函数的调用位置应指向业务代码而不是 zap.go
+	caller := fmt.Sprintf(`/%s:%d"`, filepath.Base(file), line+1)
+	if !strings.Contains(content, caller) {
+		t.Fatalf("unexpected caller in log:\n%s", content)
+	}
+}
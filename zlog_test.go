@@ -53,7 +53,7 @@ func TestNewAtomicLevelAt(t *testing.T) {
 }
 
 func TestMangets(t *testing.T) {
-	mgr := NewManager()
+	mgr, _ := newTestManager(t)
 	mgr.SetLog(
 		ENV_WARN,
 		WithRotationTime(12),
@@ -98,7 +98,7 @@ func TestManagerDynamicDebugLevel(t *testing.T) {
 	t.Log("=== 测试 Manager 动态修改日志级别 ===")
 
 	// 创建独立的 Manager
-	mgr := NewManager()
+	mgr, _ := newTestManager(t)
 
 	// 1. 设置为 PRO 环境
 	t.Log("步骤1: 设置为 PRO 环境")
@@ -155,7 +155,7 @@ func TestAllLevelSetters(t *testing.T) {
 
 	// 测试 Manager 实例方法
 	t.Log("\n测试 Manager 实例方法:")
-	mgr := NewManager()
+	mgr, _ := newTestManager(t)
 
 	mgr.SetDebugLevel()
 	t.Log("- Manager.SetDebugLevel() 完成")
@@ -273,7 +273,7 @@ func TestConcurrentDynamicLevel(t *testing.T) {
 func TestConcurrentManagerDynamicLevel(t *testing.T) {
 	t.Log("=== 开始Manager并发压测 ===")
 
-	mgr := NewManager()
+	mgr, _ := newTestManager(t)
 	mgr.SetLog(ENV_PRO)
 
 	numGoroutines := 50
@@ -7,7 +7,6 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
 
 	rotatelogs "github.com/lestrrat-go/file-rotatelogs"
@@ -21,14 +20,20 @@ func newInfoWriter(cfg Config, fileName string) (zapcore.WriteSyncer, error) {
 		return nil, err
 	}
 
-	fileWriter, err := rotatelogs.New(
-		strings.Replace(fileName, ".log", "", -1)+"%Y-%m-%d.log",
+	period := cfg.rotationPeriod()
+	fileWriter, err := newRotateWriter(
+		rotationPattern(fileName, period),
 		rotatelogs.WithLinkName(fileName),
 		rotatelogs.WithMaxAge(time.Duration(cfg.WithMaxAge)*time.Hour),
-		rotatelogs.WithRotationTime(time.Duration(cfg.WithRotationTime)*time.Hour),
+		rotatelogs.WithRotationTime(period),
+		rotatelogs.WithLocation(location),
 	)
+	if err != nil {
+		return nil, err
+	}
+	_ = registerLogFile(fileName)
 	// 只返回文件 writer，终端输出由 buildLogger 中的独立 core 处理
-	return zapcore.AddSync(fileWriter), err
+	return fileWriter, nil
 }
 
 // newErrorWriter 创建 error 日志专用的滚动写入器。
@@ -38,13 +43,19 @@ func newErrorWriter(cfg Config, fileName string) (zapcore.WriteSyncer, error) {
 		return nil, err
 	}
 
-	fileWriter, err := rotatelogs.New(
-		strings.Replace(fileName, ".log", "", -1)+"%Y-%m-%d.log",
+	period := cfg.rotationPeriod()
+	fileWriter, err := newRotateWriter(
+		rotationPattern(fileName, period),
 		rotatelogs.WithLinkName(fileName),
 		rotatelogs.WithMaxAge(time.Duration(cfg.WithMaxAge)*time.Hour),
-		rotatelogs.WithRotationTime(time.Duration(cfg.WithRotationTime)*time.Hour),
+		rotatelogs.WithRotationTime(period),
+		rotatelogs.WithLocation(location),
 	)
-	return zapcore.AddSync(fileWriter), err
+	if err != nil {
+		return nil, err
+	}
+	_ = registerLogFile(fileName)
+	return fileWriter, nil
 }
 
 // SetZapOut 将标准库 log 输出重定向到滚动日志。
@@ -59,18 +70,19 @@ func (m *Manager) SetZapOut(fileName string) error {
 	}
 
 	fileWriter, err := rotatelogs.New(
-		strings.Replace(fileName, ".log", "", -1)+"%Y-%m-%d.log",
+		rotationPattern(fileName, 24*time.Hour),
 		rotatelogs.WithLinkName(fileName),
 		rotatelogs.WithRotationCount(7),
 		rotatelogs.WithRotationSize(1024*1024*10),
 	)
+	if err != nil {
+		return err
+	}
+	_ = registerLogFile(fileName)
 	var w zapcore.WriteSyncer
 	// 支持通过 Env 或 Level 来控制终端输出
 	if cfg.Env == ENV_DEBUG || cfg.Level == zapcore.DebugLevel {
-		w, err = zapcore.NewMultiWriteSyncer(zapcore.AddSync(os.Stdout), zapcore.AddSync(fileWriter)), err
-		if err != nil {
-			return err
-		}
+		w = zapcore.NewMultiWriteSyncer(zapcore.AddSync(os.Stdout), zapcore.AddSync(fileWriter))
 	} else {
 		w = zapcore.AddSync(fileWriter)
 	}